@@ -0,0 +1,313 @@
+package rbtree
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// AtomicRBTree is an RBTree for read-mostly workloads that is safe for
+// concurrent use. Readers never block: each query loads the current version
+// of the tree with a single atomic read and runs against it, so it always sees
+// a consistent tree. Writers are serialized; each one copies the path it
+// modifies into a new version and publishes it with an atomic swap, so a write
+// costs O(log n) allocations.
+type AtomicRBTree[K, V any] struct {
+	mu      sync.Mutex
+	current atomic.Pointer[RBTree[K, V]]
+}
+
+// NewAtomic returns an AtomicRBTree starting from a snapshot of tree. Later
+// changes to tree are not visible through the AtomicRBTree.
+func NewAtomic[K, V any](tree *RBTree[K, V]) *AtomicRBTree[K, V] {
+	a := &AtomicRBTree[K, V]{}
+	a.current.Store(tree.Snapshot())
+	return a
+}
+
+// load returns the published version. It must not be modified.
+func (a *AtomicRBTree[K, V]) load() *RBTree[K, V] {
+	return a.current.Load()
+}
+
+// Write calls fn with a private copy of the current version and then
+// publishes the copy, so several changes become visible to readers at once.
+// fn must not keep the tree after it returns.
+func (a *AtomicRBTree[K, V]) Write(fn func(tree *RBTree[K, V])) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		fn(tree)
+		return true
+	})
+}
+
+// Snapshot returns a copy of the current version that the caller may read and
+// modify freely. Changes to it are not visible through a.
+func (a *AtomicRBTree[K, V]) Snapshot() *RBTree[K, V] {
+	snapshot := a.load().clone()
+	snapshot.ownCounters()
+	return snapshot
+}
+
+// Stats returns the statistics of the current version. Every version shares
+// the counters, so they cover all operations on a.
+func (a *AtomicRBTree[K, V]) Stats() Stats {
+	return a.load().Stats()
+}
+
+func (a *AtomicRBTree[K, V]) ResetStats() {
+	a.load().ResetStats()
+}
+
+// CountComparisons publishes a version with counting turned on or off.
+// Readers that loaded an earlier version keep its setting.
+func (a *AtomicRBTree[K, V]) CountComparisons(on bool) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		tree.CountComparisons(on)
+		return true
+	})
+}
+
+// update calls fn with a private copy of the current version and publishes
+// the copy if fn reports that it changed it.
+func (a *AtomicRBTree[K, V]) update(fn func(tree *RBTree[K, V]) bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	next := a.load().clone()
+	if fn(next) {
+		a.current.Store(next)
+	}
+}
+
+func (a *AtomicRBTree[K, V]) Put(key K, value V) (old K, oldValue V, replaced bool) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		old, oldValue, replaced = tree.Put(key, value)
+		return true
+	})
+	return old, oldValue, replaced
+}
+
+// Update is RBTree.Update applied to a new version, which is published only if
+// fn stores a value or deletes a key that was present. fn must not use a.
+func (a *AtomicRBTree[K, V]) Update(key K, fn func(old V, found bool) (V, Action)) (value V, ok bool) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		changed := false
+		value, ok = tree.Update(key, func(old V, found bool) (V, Action) {
+			value, action := fn(old, found)
+			changed = action == StoreValue || action == DeleteKey && found
+			return value, action
+		})
+		return changed
+	})
+	return value, ok
+}
+
+func (a *AtomicRBTree[K, V]) GetOrInsert(key K, value V) (V, bool) {
+	return getOrInsert[K, V](a, key, value)
+}
+
+func (a *AtomicRBTree[K, V]) Delete(key K) (removed K, value V, found bool) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		removed, value, found = tree.Delete(key)
+		return found
+	})
+	return removed, value, found
+}
+
+func (a *AtomicRBTree[K, V]) DeleteMin() (removed K, value V, found bool) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		removed, value, found = tree.DeleteMin()
+		return found
+	})
+	return removed, value, found
+}
+
+func (a *AtomicRBTree[K, V]) DeleteMax() (removed K, value V, found bool) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		removed, value, found = tree.DeleteMax()
+		return found
+	})
+	return removed, value, found
+}
+
+func (a *AtomicRBTree[K, V]) DeleteRange(lo K, hi K) (removed int) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		removed = tree.DeleteRange(lo, hi)
+		return removed > 0
+	})
+	return removed
+}
+
+func (a *AtomicRBTree[K, V]) DeleteBefore(key K) (removed int) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		removed = tree.DeleteBefore(key)
+		return removed > 0
+	})
+	return removed
+}
+
+func (a *AtomicRBTree[K, V]) DeleteAfter(key K) (removed int) {
+	a.update(func(tree *RBTree[K, V]) bool {
+		removed = tree.DeleteAfter(key)
+		return removed > 0
+	})
+	return removed
+}
+
+func (a *AtomicRBTree[K, V]) Size() int {
+	return a.load().Size()
+}
+
+func (a *AtomicRBTree[K, V]) IsEmpty() bool {
+	return a.load().IsEmpty()
+}
+
+func (a *AtomicRBTree[K, V]) Get(key K) (V, bool) {
+	return a.load().Get(key)
+}
+
+func (a *AtomicRBTree[K, V]) Contains(key K) bool {
+	return a.load().Contains(key)
+}
+
+func (a *AtomicRBTree[K, V]) Min() (K, bool) {
+	return a.load().Min()
+}
+
+func (a *AtomicRBTree[K, V]) Max() (K, bool) {
+	return a.load().Max()
+}
+
+func (a *AtomicRBTree[K, V]) Floor(key K) (K, bool) {
+	return a.load().Floor(key)
+}
+
+func (a *AtomicRBTree[K, V]) Ceiling(key K) (K, bool) {
+	return a.load().Ceiling(key)
+}
+
+func (a *AtomicRBTree[K, V]) Lower(key K) (K, bool) {
+	return a.load().Lower(key)
+}
+
+func (a *AtomicRBTree[K, V]) Higher(key K) (K, bool) {
+	return a.load().Higher(key)
+}
+
+func (a *AtomicRBTree[K, V]) Predecessor(key K) (K, bool) {
+	return a.load().Lower(key)
+}
+
+func (a *AtomicRBTree[K, V]) Successor(key K) (K, bool) {
+	return a.load().Higher(key)
+}
+
+func (a *AtomicRBTree[K, V]) Neighbours(key K) (K, bool, K, bool) {
+	return a.load().Neighbours(key)
+}
+
+func (a *AtomicRBTree[K, V]) Rank(key K) int {
+	return a.load().Rank(key)
+}
+
+func (a *AtomicRBTree[K, V]) RankFloor(key K) (int, bool) {
+	return a.load().RankFloor(key)
+}
+
+func (a *AtomicRBTree[K, V]) RankCeiling(key K) (int, bool) {
+	return a.load().RankCeiling(key)
+}
+
+func (a *AtomicRBTree[K, V]) CountInRange(lo K, hi K, bounds Bounds) int {
+	return a.load().CountInRange(lo, hi, bounds)
+}
+
+func (a *AtomicRBTree[K, V]) Select(k int) (K, bool) {
+	return a.load().Select(k)
+}
+
+func (a *AtomicRBTree[K, V]) Height() int {
+	return a.load().Height()
+}
+
+func (a *AtomicRBTree[K, V]) Keys() []K {
+	return a.load().Keys()
+}
+
+func (a *AtomicRBTree[K, V]) KeysInRange(lo K, hi K) []K {
+	return a.load().KeysInRange(lo, hi)
+}
+
+func (a *AtomicRBTree[K, V]) KeysDesc() []K {
+	return a.load().KeysDesc()
+}
+
+func (a *AtomicRBTree[K, V]) KeysInRangeDesc(hi K, lo K) []K {
+	return a.load().KeysInRangeDesc(hi, lo)
+}
+
+func (a *AtomicRBTree[K, V]) Values() []V {
+	return a.load().Values()
+}
+
+func (a *AtomicRBTree[K, V]) ValuesInRange(lo K, hi K) []V {
+	return a.load().ValuesInRange(lo, hi)
+}
+
+// view returns a copy of the published version for a scan. Versions never
+// change once published, so unlike RBTree.view it need not freeze anything,
+// and must not: the scan would write to a tree other readers share.
+func (a *AtomicRBTree[K, V]) view() *RBTree[K, V] {
+	return a.load().clone()
+}
+
+// KeysCh, KeysInRangeCh, their descending versions and KeysInRangeCtx scan
+// the version current at the time of the call, so writes made during the
+// scan are not seen.
+
+func (a *AtomicRBTree[K, V]) KeysCh(quit <-chan struct{}) <-chan K {
+	return a.view().KeysCh(quit)
+}
+
+func (a *AtomicRBTree[K, V]) KeysInRangeCh(quit <-chan struct{}, lo K, hi K) <-chan K {
+	return a.view().KeysInRangeCh(quit, lo, hi)
+}
+
+func (a *AtomicRBTree[K, V]) KeysDescCh(quit <-chan struct{}, limit int) <-chan K {
+	return a.view().KeysDescCh(quit, limit)
+}
+
+func (a *AtomicRBTree[K, V]) KeysInRangeDescCh(quit <-chan struct{}, hi K, lo K, limit int) <-chan K {
+	return a.view().KeysInRangeDescCh(quit, hi, lo, limit)
+}
+
+func (a *AtomicRBTree[K, V]) KeysInRangeCtx(ctx context.Context, lo K, hi K, opts ScanOptions) *KeyScan[K] {
+	return a.view().KeysInRangeCtx(ctx, lo, hi, opts)
+}
+
+// All, Backward, Range and RangeDesc iterate over the version current when the
+// loop starts, so the loop body may write to a.
+
+func (a *AtomicRBTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		a.view().All()(yield)
+	}
+}
+
+func (a *AtomicRBTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		a.view().Backward()(yield)
+	}
+}
+
+func (a *AtomicRBTree[K, V]) Range(lo K, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		a.view().Range(lo, hi)(yield)
+	}
+}
+
+func (a *AtomicRBTree[K, V]) RangeDesc(hi K, lo K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		a.view().RangeDesc(hi, lo)(yield)
+	}
+}
@@ -0,0 +1,101 @@
+package rbtree
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAtomicReadersSeeConsistentVersions(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestAtomicReadersSeeConsistentVersions")
+	tree := NewAtomic(NewOrdered[int, int]())
+
+	const readers = 4
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// the writer always puts 0..n-1 in order, so every version is
+				// a prefix of the keys
+				var count int = 0
+				for key, value := range tree.All() {
+					if key != count || value != count {
+						t.Errorf("tree.All: expected: %d, got: %d", count, key)
+						return
+					}
+					count++
+				}
+				if key, ok := tree.Floor(maxKeys); ok && key < count-1 {
+					t.Errorf("tree.Floor: went backwards, expected at least: %d, got: %d", count-1, key)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < maxKeys/10; i++ {
+		tree.Put(i, i)
+	}
+	close(done)
+	wg.Wait()
+
+	if tree.Size() != maxKeys/10 {
+		t.Errorf("tree.Size(), expected: %d, got: %d", maxKeys/10, tree.Size())
+	}
+}
+
+func TestAtomicWriteAndSnapshot(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestAtomicWriteAndSnapshot")
+	tree := NewAtomic(NewOrdered[int, int]())
+	tree.Write(func(tree *RBTree[int, int]) {
+		for i := 0; i < maxKeys; i++ {
+			tree.Put(i, i)
+		}
+	})
+
+	snapshot := tree.Snapshot()
+	snapshot.DeleteMin()
+	tree.DeleteMax()
+	if _, _, ok := tree.Delete(-1); ok {
+		t.Error("tree.Delete: found missing key")
+	}
+
+	if min, _ := tree.Min(); min != 0 {
+		t.Errorf("tree.Min: expected: %d, got: %d", 0, min)
+	}
+	if max, _ := snapshot.Max(); max != maxKeys-1 {
+		t.Errorf("snapshot.Max: expected: %d, got: %d", maxKeys-1, max)
+	}
+	if tree.Size() != maxKeys-1 || snapshot.Size() != maxKeys-1 {
+		t.Errorf("Size(), expected: %d, got: %d and %d", maxKeys-1, tree.Size(), snapshot.Size())
+	}
+}
+
+func TestAtomicKeysChSnapshot(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestAtomicKeysChSnapshot")
+	tree := NewAtomic(NewOrdered[int, int]())
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+
+	var count int = 0
+	for key := range tree.KeysCh(nil) {
+		count++
+		tree.Delete(key)
+		tree.Put(key+100, key)
+	}
+	if count != 100 {
+		t.Errorf("tree.KeysCh, count wrong, expected: %d, got: %d", 100, count)
+	}
+	if min, _ := tree.Min(); min != 100 {
+		t.Errorf("tree.Min: expected: %d, got: %d", 100, min)
+	}
+}
@@ -0,0 +1,147 @@
+package rbtree
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"math/bits"
+)
+
+// ErrNotSorted is returned by the FromSorted constructors in ValidateSorted
+// mode when keys are out of order or repeated.
+var ErrNotSorted = errors.New("rbtree: keys not in strictly ascending order")
+
+// SortedMode says whether the FromSorted constructors check their input.
+type SortedMode int
+
+const (
+	// AssumeSorted trusts the caller that keys are strictly ascending. The
+	// tree is wrong in unspecified ways if they are not.
+	AssumeSorted SortedMode = iota
+	// ValidateSorted checks that keys are strictly ascending, at the cost of
+	// one comparison per key, and returns ErrNotSorted if they are not.
+	ValidateSorted
+)
+
+// FromSorted returns a tree holding keys and their values, ordered by
+// cmp.Compare, in O(n). keys must be strictly ascending. values may be nil,
+// in which case every key maps to the zero value; otherwise it must be the
+// same length as keys.
+func FromSorted[K cmp.Ordered, V any](keys []K, values []V, mode SortedMode) (*RBTree[K, V], error) {
+	return FromSortedFunc(cmp.Compare[K], keys, values, mode)
+}
+
+// FromSortedFunc is FromSorted for a tree ordered by compare.
+func FromSortedFunc[K, V any](compare func(a, b K) int, keys []K, values []V, mode SortedMode) (*RBTree[K, V], error) {
+	if values == nil {
+		values = make([]V, len(keys))
+	}
+	if len(values) != len(keys) {
+		return nil, fmt.Errorf("rbtree: %d keys but %d values", len(keys), len(values))
+	}
+	tree := NewWithComparator[K, V](compare)
+	if mode == ValidateSorted {
+		for i := 1; i < len(keys); i++ {
+			if compare(keys[i-1], keys[i]) >= 0 {
+				return nil, fmt.Errorf("%w: at index %d", ErrNotSorted, i)
+			}
+		}
+	}
+	tree.root = tree.build(keys, values)
+	return tree, nil
+}
+
+// FromSortedSeq returns a tree holding the keys and values produced by seq,
+// ordered by cmp.Compare, in O(n). seq must produce keys in strictly
+// ascending order.
+func FromSortedSeq[K cmp.Ordered, V any](seq iter.Seq2[K, V], mode SortedMode) (*RBTree[K, V], error) {
+	return FromSortedSeqFunc(cmp.Compare[K], seq, mode)
+}
+
+// FromSortedSeqFunc is FromSortedSeq for a tree ordered by compare.
+func FromSortedSeqFunc[K, V any](compare func(a, b K) int, seq iter.Seq2[K, V], mode SortedMode) (*RBTree[K, V], error) {
+	// the shape depends on the number of keys, so gather them first
+	var keys []K
+	var values []V
+	for key, value := range seq {
+		if mode == ValidateSorted && len(keys) > 0 && compare(keys[len(keys)-1], key) >= 0 {
+			return nil, fmt.Errorf("%w: at index %d", ErrNotSorted, len(keys))
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return FromSortedFunc(compare, keys, values, AssumeSorted)
+}
+
+// build returns a tree holding keys and values, which must be sorted in
+// strictly ascending key order, in O(n).
+//
+// A left-leaning red-black tree is a 2-3 tree in disguise, so build lays the
+// keys out as a 2-3 tree with every leaf at the same depth: each subtree with
+// black height h holds between 2^h-1 and 3^h-1 keys, and a 3-node becomes a
+// black node with a red left child.
+func (tree *RBTree[K, V]) build(keys []K, values []V) *Node[K, V] {
+	blackHeight := bits.Len(uint(len(keys)+1)) - 1
+	return tree.buildNode(keys, values, blackHeight)
+}
+
+func (tree *RBTree[K, V]) buildNode(keys []K, values []V, blackHeight int) *Node[K, V] {
+	n := len(keys)
+	if n == 0 {
+		return nil
+	}
+	most := maxKeys23(blackHeight - 1)
+	if n-1 <= 2*most {
+		// 2-node: split the rest evenly between the children
+		a := (n - 1) / 2
+		node := tree.addNode(keys[a], values[a], BLACK)
+		node.left = tree.buildNode(keys[:a], values[:a], blackHeight-1)
+		node.right = tree.buildNode(keys[a+1:], values[a+1:], blackHeight-1)
+		resize(node)
+		return node
+	}
+	// 3-node: split the rest evenly between the three children
+	rest := n - 2
+	a := rest / 3
+	b := (rest - a) / 2
+	red := tree.addNode(keys[a], values[a], RED)
+	red.left = tree.buildNode(keys[:a], values[:a], blackHeight-1)
+	red.right = tree.buildNode(keys[a+1:a+1+b], values[a+1:a+1+b], blackHeight-1)
+	resize(red)
+	node := tree.addNode(keys[a+1+b], values[a+1+b], BLACK)
+	node.left = red
+	node.right = tree.buildNode(keys[a+2+b:], values[a+2+b:], blackHeight-1)
+	resize(node)
+	return node
+}
+
+// maxKeys23 returns 3^h-1, the most keys a 2-3 tree of height h can hold,
+// saturating at math.MaxInt/2 so callers can double it.
+func maxKeys23(h int) int {
+	most := 1
+	for i := 0; i < h; i++ {
+		if most > math.MaxInt/6 {
+			return math.MaxInt / 2
+		}
+		most *= 3
+	}
+	return most - 1
+}
+
+// addNode returns a new node for a key the tree did not hold, and reports it
+// to the tracer.
+func (tree *RBTree[K, V]) addNode(key K, value V, colour bool) *Node[K, V] {
+	node := tree.newNode(key, value, colour)
+	tree.trace(EventNodeCreated, node)
+	return node
+}
+
+// newNode returns a new node owned by the tree. Split and join use it to
+// rebuild nodes for keys the tree already held, so it reports nothing.
+func (tree *RBTree[K, V]) newNode(key K, value V, colour bool) *Node[K, V] {
+	node := NewNode(key, value, colour, 1)
+	node.gen = tree.gen.Load()
+	return node
+}
@@ -0,0 +1,93 @@
+package rbtree
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFromSorted(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestFromSorted")
+	keys := make([]int, maxKeys)
+	values := make([]string, maxKeys)
+	for i := 0; i < maxKeys; i++ {
+		keys[i] = i
+		values[i] = strconv.Itoa(i)
+	}
+
+	tree, err := FromSorted(keys, values, ValidateSorted)
+	if err != nil {
+		t.Fatalf("FromSorted: %v", err)
+	}
+	if tree.Size() != maxKeys {
+		t.Errorf("tree.Size(), expected: %d, got: %d", maxKeys, tree.Size())
+	}
+	for i := 0; i < maxKeys; i++ {
+		if value, ok := tree.Get(i); !ok || value != values[i] {
+			t.Errorf("tree.Get: expected: %s, got: %s", values[i], value)
+		}
+		if rank := tree.Rank(i); rank != i {
+			t.Errorf("tree.Rank: expected: %d, got: %d", i, rank)
+		}
+	}
+
+	for i := 0; i < maxKeys; i += 2 {
+		tree.Delete(i)
+	}
+	tree.Put(maxKeys, "")
+	if tree.Size() != maxKeys/2+1 {
+		t.Errorf("tree.Size(), expected: %d, got: %d", maxKeys/2+1, tree.Size())
+	}
+}
+
+func TestFromSortedSeq(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestFromSortedSeq")
+	source := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		source.Put(i, i*10)
+	}
+
+	tree, err := FromSortedSeq(source.All(), ValidateSorted)
+	if err != nil {
+		t.Fatalf("FromSortedSeq: %v", err)
+	}
+	var count int = 0
+	for key, value := range tree.All() {
+		if key != count || value != count*10 {
+			t.Errorf("tree.All: expected: %d, got: %d", count, key)
+		}
+		count++
+	}
+	if count != maxKeys {
+		t.Errorf("tree.All, count wrong, expected: %d, got: %d", maxKeys, count)
+	}
+
+	descending := NewWithComparator[int, int](func(a, b int) int { return b - a })
+	for i := 0; i < 100; i++ {
+		descending.Put(i, i)
+	}
+	if _, err := FromSortedSeqFunc(descending.compare, descending.All(), ValidateSorted); err != nil {
+		t.Errorf("FromSortedSeqFunc: %v", err)
+	}
+	if _, err := FromSortedSeq(descending.All(), ValidateSorted); !errors.Is(err, ErrNotSorted) {
+		t.Errorf("FromSortedSeq: expected: %v, got: %v", ErrNotSorted, err)
+	}
+}
+
+func TestFromSortedRejects(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestFromSortedRejects")
+	if _, err := FromSorted[int, int]([]int{1, 2, 2, 3}, nil, ValidateSorted); !errors.Is(err, ErrNotSorted) {
+		t.Errorf("FromSorted duplicates: expected: %v, got: %v", ErrNotSorted, err)
+	}
+	if _, err := FromSorted[int, int]([]int{1, 3, 2}, nil, ValidateSorted); !errors.Is(err, ErrNotSorted) {
+		t.Errorf("FromSorted unsorted: expected: %v, got: %v", ErrNotSorted, err)
+	}
+	if _, err := FromSorted([]int{1, 2, 3}, []int{1}, AssumeSorted); err == nil {
+		t.Error("FromSorted: expected error for mismatched values")
+	}
+	tree, err := FromSorted[string, string]([]string{}, nil, ValidateSorted)
+	if err != nil || !tree.IsEmpty() {
+		t.Errorf("FromSorted empty: expected empty tree, got: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package rbtree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvariant is wrapped by the errors Check returns.
+var ErrInvariant = errors.New("rbtree: invariant violated")
+
+// Check verifies that the tree is a valid left-leaning red-black tree: keys
+// are in symmetric order, the root is black, no node has a red right link or
+// two red links in a row, every path from the root to a nil link has the same
+// number of black links, and every N and height match its subtree. It returns
+// nil, or an error wrapping ErrInvariant that names the first offending key.
+// Check takes O(n) time.
+func (tree *RBTree[K, V]) Check() error {
+	if isRed(tree.root) {
+		return fmt.Errorf("%w: root %v is red", ErrInvariant, tree.root.key)
+	}
+	_, err := tree.check(tree.root, nil, nil)
+	return err
+}
+
+// check validates the subtree rooted at node, whose keys must lie strictly
+// between lo and hi when they are not nil, and returns its black height.
+func (tree *RBTree[K, V]) check(node *Node[K, V], lo *K, hi *K) (int, error) {
+	if node == nil {
+		return 0, nil
+	}
+	if lo != nil && tree.compare(node.key, *lo) <= 0 {
+		return 0, fmt.Errorf("%w: key %v is not greater than %v, out of order", ErrInvariant, node.key, *lo)
+	}
+	if hi != nil && tree.compare(node.key, *hi) >= 0 {
+		return 0, fmt.Errorf("%w: key %v is not less than %v, out of order", ErrInvariant, node.key, *hi)
+	}
+	if isRed(node.right) {
+		return 0, fmt.Errorf("%w: key %v has a red right link", ErrInvariant, node.key)
+	}
+	if isRed(node) && isRed(node.left) {
+		return 0, fmt.Errorf("%w: key %v and its left child are both red", ErrInvariant, node.key)
+	}
+	if node.N != 1+size(node.left)+size(node.right) {
+		return 0, fmt.Errorf("%w: key %v has N %d, subtree size is %d",
+			ErrInvariant, node.key, node.N, 1+size(node.left)+size(node.right))
+	}
+
+	expected := 1 + height(node.left)
+	if 1+height(node.right) > expected {
+		expected = 1 + height(node.right)
+	}
+	if int(node.height) != expected {
+		return 0, fmt.Errorf("%w: key %v has height %d, subtree height is %d",
+			ErrInvariant, node.key, node.height, expected)
+	}
+
+	lbh, err := tree.check(node.left, lo, &node.key)
+	if err != nil {
+		return 0, err
+	}
+	rbh, err := tree.check(node.right, &node.key, hi)
+	if err != nil {
+		return 0, err
+	}
+	if lbh != rbh {
+		return 0, fmt.Errorf("%w: key %v has black height %d on the left and %d on the right",
+			ErrInvariant, node.key, lbh, rbh)
+	}
+	if !isRed(node) {
+		lbh++
+	}
+	return lbh, nil
+}
@@ -0,0 +1,70 @@
+package rbtree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckValid(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestCheckValid")
+	tree := NewOrdered[int, int]()
+	if err := tree.Check(); err != nil {
+		t.Errorf("tree.Check: empty tree: %v", err)
+	}
+	for i := 0; i < maxKeys; i++ {
+		tree.Put((i*7919)%maxKeys, i)
+		if i%1000 == 0 {
+			if err := tree.Check(); err != nil {
+				t.Fatalf("tree.Check after Put: %v", err)
+			}
+		}
+	}
+	for i := 0; i < maxKeys; i += 3 {
+		tree.Delete((i * 104729) % maxKeys)
+		tree.DeleteMin()
+		tree.DeleteMax()
+		if i%999 == 0 {
+			if err := tree.Check(); err != nil {
+				t.Fatalf("tree.Check after Delete: %v", err)
+			}
+		}
+	}
+	if err := tree.Check(); err != nil {
+		t.Errorf("tree.Check: %v", err)
+	}
+}
+
+func TestCheckViolations(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestCheckViolations")
+	valid := func() *RBTree[int, int] {
+		tree, _ := FromSorted[int, int]([]int{1, 2, 3, 4, 5, 6, 7}, nil, AssumeSorted)
+		return tree
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(root *Node[int, int])
+		message string
+	}{
+		{"order", func(root *Node[int, int]) { root.left.right.key = 5 }, "out of order"},
+		{"red right", func(root *Node[int, int]) { root.right.right.colour = RED }, "red right link"},
+		{"red red", func(root *Node[int, int]) { root.left.colour = RED; root.left.left.colour = RED }, "both red"},
+		{"black height", func(root *Node[int, int]) { root.left.left.colour = RED }, "black height"},
+		{"size", func(root *Node[int, int]) { root.right.N = 7 }, "subtree size"},
+		{"height", func(root *Node[int, int]) { root.right.height = 5 }, "subtree height"},
+		{"red root", func(root *Node[int, int]) { root.colour = RED }, "is red"},
+	}
+	for _, test := range tests {
+		tree := valid()
+		if err := tree.Check(); err != nil {
+			t.Fatalf("tree.Check: %v", err)
+		}
+		test.corrupt(tree.root)
+		err := tree.Check()
+		if !errors.Is(err, ErrInvariant) || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("tree.Check %s: expected %q, got: %v", test.name, test.message, err)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package rbtree
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// ConcurrentRBTree is an RBTree that is safe for concurrent use. Queries
+// share a read lock and run in parallel; Put, Delete, DeleteMin and DeleteMax
+// take the write lock and are serialized. Channel and iterator scans walk a
+// frozen view of the tree and hold no lock while they run.
+type ConcurrentRBTree[K, V any] struct {
+	mu   sync.RWMutex
+	tree *RBTree[K, V]
+}
+
+// NewConcurrent returns a ConcurrentRBTree guarding tree. The caller must not
+// use tree directly afterwards.
+func NewConcurrent[K, V any](tree *RBTree[K, V]) *ConcurrentRBTree[K, V] {
+	return &ConcurrentRBTree[K, V]{tree: tree}
+}
+
+// Read calls fn with the read lock held. fn must not modify the tree.
+func (c *ConcurrentRBTree[K, V]) Read(fn func(tree *RBTree[K, V])) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fn(c.tree)
+}
+
+// Write calls fn with the write lock held, so several changes can be made
+// atomically.
+func (c *ConcurrentRBTree[K, V]) Write(fn func(tree *RBTree[K, V])) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(c.tree)
+}
+
+// Snapshot returns an unsynchronized copy of the tree as it is now. It takes
+// the write lock only for the O(1) copy, and the copy is unaffected by later
+// writes to c.
+func (c *ConcurrentRBTree[K, V]) Snapshot() *RBTree[K, V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.Snapshot()
+}
+
+// Stats takes the lock only for the O(1) view, which shares the tree's
+// counters, and walks the view for the shape with no lock held, so it never
+// holds up writers for O(n).
+func (c *ConcurrentRBTree[K, V]) Stats() Stats {
+	return c.view().Stats()
+}
+
+func (c *ConcurrentRBTree[K, V]) ResetStats() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.tree.ResetStats()
+}
+
+func (c *ConcurrentRBTree[K, V]) CountComparisons(on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tree.CountComparisons(on)
+}
+
+func (c *ConcurrentRBTree[K, V]) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Size()
+}
+
+func (c *ConcurrentRBTree[K, V]) IsEmpty() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.IsEmpty()
+}
+
+func (c *ConcurrentRBTree[K, V]) Put(key K, value V) (K, V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.Put(key, value)
+}
+
+// Update is RBTree.Update with the write lock held, so fn sees and decides on
+// the value atomically. fn must not use c.
+func (c *ConcurrentRBTree[K, V]) Update(key K, fn func(old V, found bool) (V, Action)) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.Update(key, fn)
+}
+
+func (c *ConcurrentRBTree[K, V]) GetOrInsert(key K, value V) (V, bool) {
+	return getOrInsert[K, V](c, key, value)
+}
+
+func (c *ConcurrentRBTree[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Get(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Contains(key K) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Contains(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Delete(key K) (K, V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.Delete(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) DeleteMin() (K, V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.DeleteMin()
+}
+
+func (c *ConcurrentRBTree[K, V]) DeleteMax() (K, V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.DeleteMax()
+}
+
+func (c *ConcurrentRBTree[K, V]) DeleteRange(lo K, hi K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.DeleteRange(lo, hi)
+}
+
+func (c *ConcurrentRBTree[K, V]) DeleteBefore(key K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.DeleteBefore(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) DeleteAfter(key K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.DeleteAfter(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Min() (K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Min()
+}
+
+func (c *ConcurrentRBTree[K, V]) Max() (K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Max()
+}
+
+func (c *ConcurrentRBTree[K, V]) Floor(key K) (K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Floor(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Ceiling(key K) (K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Ceiling(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Lower(key K) (K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Lower(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Higher(key K) (K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Higher(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Predecessor(key K) (K, bool) {
+	return c.Lower(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Successor(key K) (K, bool) {
+	return c.Higher(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Neighbours(key K) (K, bool, K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Neighbours(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) Rank(key K) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Rank(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) RankFloor(key K) (int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.RankFloor(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) RankCeiling(key K) (int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.RankCeiling(key)
+}
+
+func (c *ConcurrentRBTree[K, V]) CountInRange(lo K, hi K, bounds Bounds) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.CountInRange(lo, hi, bounds)
+}
+
+func (c *ConcurrentRBTree[K, V]) Select(k int) (K, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Select(k)
+}
+
+func (c *ConcurrentRBTree[K, V]) Height() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Height()
+}
+
+func (c *ConcurrentRBTree[K, V]) Keys() []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Keys()
+}
+
+func (c *ConcurrentRBTree[K, V]) KeysInRange(lo K, hi K) []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.KeysInRange(lo, hi)
+}
+
+func (c *ConcurrentRBTree[K, V]) KeysDesc() []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.KeysDesc()
+}
+
+func (c *ConcurrentRBTree[K, V]) KeysInRangeDesc(hi K, lo K) []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.KeysInRangeDesc(hi, lo)
+}
+
+func (c *ConcurrentRBTree[K, V]) Values() []V {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.Values()
+}
+
+func (c *ConcurrentRBTree[K, V]) ValuesInRange(lo K, hi K) []V {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree.ValuesInRange(lo, hi)
+}
+
+// view takes the write lock only to freeze the tree and copy it, in O(1), so
+// scans of the view need no lock and never hold up writers.
+func (c *ConcurrentRBTree[K, V]) view() *RBTree[K, V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tree.view()
+}
+
+// KeysCh, KeysInRangeCh, their descending versions and KeysInRangeCtx scan a
+// view of the tree taken at the time of the call, without holding a lock, so
+// writes made during the scan are neither blocked nor seen.
+
+func (c *ConcurrentRBTree[K, V]) KeysCh(quit <-chan struct{}) <-chan K {
+	return c.view().KeysCh(quit)
+}
+
+func (c *ConcurrentRBTree[K, V]) KeysInRangeCh(quit <-chan struct{}, lo K, hi K) <-chan K {
+	return c.view().KeysInRangeCh(quit, lo, hi)
+}
+
+func (c *ConcurrentRBTree[K, V]) KeysDescCh(quit <-chan struct{}, limit int) <-chan K {
+	return c.view().KeysDescCh(quit, limit)
+}
+
+func (c *ConcurrentRBTree[K, V]) KeysInRangeDescCh(quit <-chan struct{}, hi K, lo K, limit int) <-chan K {
+	return c.view().KeysInRangeDescCh(quit, hi, lo, limit)
+}
+
+func (c *ConcurrentRBTree[K, V]) KeysInRangeCtx(ctx context.Context, lo K, hi K, opts ScanOptions) *KeyScan[K] {
+	return c.view().KeysInRangeCtx(ctx, lo, hi, opts)
+}
+
+// All, Backward, Range and RangeDesc iterate over a view of the tree taken
+// when the loop starts, without holding a lock, so the loop body may write to
+// c.
+
+func (c *ConcurrentRBTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.view().All()(yield)
+	}
+}
+
+func (c *ConcurrentRBTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.view().Backward()(yield)
+	}
+}
+
+func (c *ConcurrentRBTree[K, V]) Range(lo K, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.view().Range(lo, hi)(yield)
+	}
+}
+
+func (c *ConcurrentRBTree[K, V]) RangeDesc(hi K, lo K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.view().RangeDesc(hi, lo)(yield)
+	}
+}
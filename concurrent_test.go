@@ -0,0 +1,262 @@
+package rbtree
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentPutGet(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestConcurrentPutGet")
+	tree := NewConcurrent(NewOrdered[int, int]())
+
+	const writers = 4
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < maxKeys; i += writers {
+				tree.Put(i, i)
+			}
+		}(w)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < maxKeys; i++ {
+				if value, ok := tree.Get(i); ok && value != i {
+					t.Errorf("tree.Get: expected: %d, got: %d", i, value)
+				}
+				tree.Floor(i)
+				tree.Rank(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if tree.Size() != maxKeys {
+		t.Errorf("tree.Size(), expected: %d, got: %d", maxKeys, tree.Size())
+	}
+	var count int = 0
+	for key := range tree.All() {
+		if key != count {
+			t.Errorf("tree.All: expected: %d, got: %d", count, key)
+		}
+		count++
+	}
+	if count != maxKeys {
+		t.Errorf("tree.All, count wrong, expected: %d, got: %d", maxKeys, count)
+	}
+}
+
+func TestConcurrentKeysChReleasesLock(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestConcurrentKeysChReleasesLock")
+	tree := NewConcurrent(NewOrdered[int, int]())
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	quit := make(chan struct{})
+	keys := tree.KeysInRangeCh(quit, 100, 200)
+	<-keys
+	close(quit)
+	for range keys {
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tree.DeleteMin()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tree.DeleteMin: blocked after scan was abandoned")
+	}
+	if min, _ := tree.Min(); min != 1 {
+		t.Errorf("tree.Min: expected: %d, got: %d", 1, min)
+	}
+}
+
+func TestConcurrentKeysDescChReleasesLock(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestConcurrentKeysDescChReleasesLock")
+	tree := NewConcurrent(NewOrdered[int, int]())
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	for range tree.KeysDescCh(nil, 5) {
+	}
+	quit := make(chan struct{})
+	keys := tree.KeysInRangeDescCh(quit, 200, 100, 0)
+	if key := <-keys; key != 200 {
+		t.Errorf("tree.KeysInRangeDescCh: expected: %d, got: %d", 200, key)
+	}
+	close(quit)
+	for range keys {
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tree.DeleteMax()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tree.DeleteMax: blocked after scan was abandoned")
+	}
+}
+
+func TestConcurrentKeysInRangeCtxReleasesLock(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestConcurrentKeysInRangeCtxReleasesLock")
+	tree := NewConcurrent(NewOrdered[int, int]())
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scan := tree.KeysInRangeCtx(ctx, 100, 200, ScanOptions{})
+	<-scan.C
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		tree.DeleteMin()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tree.DeleteMin: blocked after scan was cancelled")
+	}
+}
+
+func TestConcurrentScanDuringWrites(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestConcurrentScanDuringWrites")
+	tree := NewConcurrent(NewOrdered[int, int]())
+	for i := 0; i < maxKeys; i += 2 {
+		tree.Put(i, i)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; ; i = (i + 2) % maxKeys {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			tree.Put(i, i)
+			tree.Delete(i)
+		}
+	}()
+
+	// the writer toggles odd keys, so a scan may see some of them, but it must
+	// see every even key, in order
+	for round := 0; round < 10; round++ {
+		var expected int = 0
+		var last int = -1
+		for key := range tree.KeysCh(nil) {
+			if key <= last {
+				t.Fatalf("tree.KeysCh: %d after %d", key, last)
+			}
+			last = key
+			if key%2 == 1 {
+				continue
+			}
+			if key != expected {
+				t.Fatalf("tree.KeysCh: expected: %d, got: %d", expected, key)
+			}
+			expected += 2
+		}
+		if expected != maxKeys {
+			t.Fatalf("tree.KeysCh, stopped early at: %d", expected)
+		}
+		expected = maxKeys - 2
+		last = maxKeys
+		for key := range tree.Backward() {
+			if key >= last {
+				t.Fatalf("tree.Backward: %d after %d", key, last)
+			}
+			last = key
+			if key%2 == 1 {
+				continue
+			}
+			if key != expected {
+				t.Fatalf("tree.Backward: expected: %d, got: %d", expected, key)
+			}
+			expected -= 2
+		}
+		if expected != -2 {
+			t.Fatalf("tree.Backward, stopped early at: %d", expected)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	for key := range tree.All() {
+		tree.Delete(key)
+	}
+	if !tree.IsEmpty() {
+		t.Errorf("tree.Size: expected: %d, got: %d", 0, tree.Size())
+	}
+}
+
+func TestConcurrentReadScans(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestConcurrentReadScans")
+	tree := NewConcurrent(NewOrdered[int, int]())
+	tree.Put(1, 1)
+
+	// the plain iterators, Split and Snapshot only read the tree, so readers
+	// sharing the read lock may all run them at once
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tree.Read(func(tree *RBTree[int, int]) {
+				for range tree.All() {
+				}
+				tree.Split(1)
+				tree.Snapshot()
+			})
+		}()
+	}
+	wg.Wait()
+	tree.Put(2, 2)
+	if tree.Size() != 2 {
+		t.Errorf("tree.Size: expected: %d, got: %d", 2, tree.Size())
+	}
+}
+
+func TestConcurrentStatsDuringWrites(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestConcurrentStatsDuringWrites")
+	tree := NewConcurrent(NewOrdered[int, int]())
+	for i := 0; i < 1000; i++ {
+		tree.Put(i, i)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1000; i < 2000; i++ {
+			tree.Put(i, i)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		stats := tree.Stats()
+		if stats.Size < 1000 || stats.Comparisons == 0 || stats.Rotations == 0 {
+			t.Errorf("tree.Stats: got: %+v", stats)
+		}
+	}
+	wg.Wait()
+	if stats := tree.Stats(); stats.Size != 2000 {
+		t.Errorf("tree.Stats: size expected: %d, got: %d", 2000, stats.Size)
+	}
+}
@@ -0,0 +1,186 @@
+package rbtree
+
+// Cursor is a position in a tree that can be moved forwards and backwards
+// through the keys in order. Stepping with Next or Prev is amortized O(1);
+// positioning with SeekGE, SeekLE, First or Last is O(log n).
+//
+// A cursor is invalidated by any change to its tree: Next and Prev panic with
+// ErrModified if the tree changed since the cursor was positioned. After a Put
+// or Delete, reposition the cursor with First, Last or one of the Seek methods
+// before stepping it again.
+type Cursor[K, V any] struct {
+	tree *RBTree[K, V]
+	// stack holds the path from the root to the current node, which is on top.
+	stack []*Node[K, V]
+	// mods is the tree's modification count when the cursor was positioned.
+	mods uint64
+}
+
+// Cursor returns a new cursor over tree. The cursor is not positioned; call
+// First, Last, SeekGE or SeekLE before reading from it.
+func (tree *RBTree[K, V]) Cursor() *Cursor[K, V] {
+	return &Cursor[K, V]{tree: tree}
+}
+
+// Valid reports whether the cursor is positioned on a key.
+func (c *Cursor[K, V]) Valid() bool {
+	return len(c.stack) > 0
+}
+
+// Key returns the key the cursor is positioned on. It panics if the cursor is
+// not valid.
+func (c *Cursor[K, V]) Key() K {
+	return c.current().key
+}
+
+// Value returns the value the cursor is positioned on. It panics if the
+// cursor is not valid.
+func (c *Cursor[K, V]) Value() V {
+	return c.current().value
+}
+
+func (c *Cursor[K, V]) current() *Node[K, V] {
+	if !c.Valid() {
+		panic("rbtree: cursor is not positioned on a key")
+	}
+	return c.stack[len(c.stack)-1]
+}
+
+// First moves the cursor to the smallest key, returning false if the tree is
+// empty.
+func (c *Cursor[K, V]) First() bool {
+	c.stack = c.stack[:0]
+	c.mods = c.tree.mods
+	c.pushLeft(c.tree.root)
+	return c.Valid()
+}
+
+// Last moves the cursor to the largest key, returning false if the tree is
+// empty.
+func (c *Cursor[K, V]) Last() bool {
+	c.stack = c.stack[:0]
+	c.mods = c.tree.mods
+	c.pushRight(c.tree.root)
+	return c.Valid()
+}
+
+// SeekGE moves the cursor to the smallest key greater than or equal to key,
+// returning false if there is none.
+func (c *Cursor[K, V]) SeekGE(key K) bool {
+	c.stack = c.stack[:0]
+	c.mods = c.tree.mods
+	found := -1
+	node := c.tree.root
+	for node != nil {
+		c.stack = append(c.stack, node)
+		cmp := c.tree.compare(key, node.key)
+		if cmp == 0 {
+			return true
+		} else if cmp < 0 {
+			found = len(c.stack) - 1
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	c.stack = c.stack[:found+1]
+	return c.Valid()
+}
+
+// SeekLE moves the cursor to the largest key less than or equal to key,
+// returning false if there is none.
+func (c *Cursor[K, V]) SeekLE(key K) bool {
+	c.stack = c.stack[:0]
+	c.mods = c.tree.mods
+	found := -1
+	node := c.tree.root
+	for node != nil {
+		c.stack = append(c.stack, node)
+		cmp := c.tree.compare(key, node.key)
+		if cmp == 0 {
+			return true
+		} else if cmp > 0 {
+			found = len(c.stack) - 1
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+	c.stack = c.stack[:found+1]
+	return c.Valid()
+}
+
+// Next moves the cursor to the next key in ascending order, returning false
+// if the cursor was on the largest key. An invalid cursor stays invalid.
+func (c *Cursor[K, V]) Next() bool {
+	if !c.Valid() {
+		return false
+	}
+	c.checkMods()
+	node := c.stack[len(c.stack)-1]
+	if node.right != nil {
+		c.pushLeft(node.right)
+		return true
+	}
+	// climb until we leave a left subtree; that parent is the successor
+	for {
+		c.stack = c.stack[:len(c.stack)-1]
+		if !c.Valid() {
+			return false
+		}
+		parent := c.stack[len(c.stack)-1]
+		if parent.left == node {
+			return true
+		}
+		node = parent
+	}
+}
+
+// Prev moves the cursor to the previous key in ascending order, returning
+// false if the cursor was on the smallest key. An invalid cursor stays
+// invalid.
+func (c *Cursor[K, V]) Prev() bool {
+	if !c.Valid() {
+		return false
+	}
+	c.checkMods()
+	node := c.stack[len(c.stack)-1]
+	if node.left != nil {
+		c.pushRight(node.left)
+		return true
+	}
+	// climb until we leave a right subtree; that parent is the predecessor
+	for {
+		c.stack = c.stack[:len(c.stack)-1]
+		if !c.Valid() {
+			return false
+		}
+		parent := c.stack[len(c.stack)-1]
+		if parent.right == node {
+			return true
+		}
+		node = parent
+	}
+}
+
+// checkMods panics with ErrModified if the tree changed since the cursor was
+// positioned.
+func (c *Cursor[K, V]) checkMods() {
+	if c.mods != c.tree.mods {
+		panic(ErrModified)
+	}
+}
+
+func (c *Cursor[K, V]) pushLeft(node *Node[K, V]) {
+	for node != nil {
+		c.stack = append(c.stack, node)
+		node = node.left
+	}
+}
+
+func (c *Cursor[K, V]) pushRight(node *Node[K, V]) {
+	for node != nil {
+		c.stack = append(c.stack, node)
+		node = node.right
+	}
+}
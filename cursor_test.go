@@ -0,0 +1,110 @@
+package rbtree
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorNextPrev(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestCursorNextPrev")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i*10)
+	}
+
+	c := tree.Cursor()
+	if c.Valid() {
+		t.Error("cursor.Valid: new cursor should not be positioned")
+	}
+
+	var count int = 0
+	for ok := c.First(); ok; ok = c.Next() {
+		if c.Key() != count || c.Value() != count*10 {
+			t.Errorf("cursor.Next: expected: %d, got: %d", count, c.Key())
+		}
+		count++
+	}
+	if count != maxKeys {
+		t.Errorf("cursor.Next, count wrong, expected: %d, got: %d", maxKeys, count)
+	}
+
+	for ok := c.Last(); ok; ok = c.Prev() {
+		count--
+		if c.Key() != count {
+			t.Errorf("cursor.Prev: expected: %d, got: %d", count, c.Key())
+		}
+	}
+	if count != 0 {
+		t.Errorf("cursor.Prev, stopped early at: %d", count)
+	}
+}
+
+func TestCursorSeek(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestCursorSeek")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i += 2 {
+		tree.Put(i, i)
+	}
+
+	c := tree.Cursor()
+	if !c.SeekGE(2001) || c.Key() != 2002 {
+		t.Errorf("cursor.SeekGE: expected: %d, got: %d", 2002, c.Key())
+	}
+	if !c.Prev() || c.Key() != 2000 {
+		t.Errorf("cursor.Prev: expected: %d, got: %d", 2000, c.Key())
+	}
+	if !c.SeekLE(2001) || c.Key() != 2000 {
+		t.Errorf("cursor.SeekLE: expected: %d, got: %d", 2000, c.Key())
+	}
+	if !c.Next() || c.Key() != 2002 {
+		t.Errorf("cursor.Next: expected: %d, got: %d", 2002, c.Key())
+	}
+	if !c.SeekGE(2004) || c.Key() != 2004 {
+		t.Errorf("cursor.SeekGE: expected: %d, got: %d", 2004, c.Key())
+	}
+
+	if c.SeekGE(maxKeys) {
+		t.Errorf("cursor.SeekGE: expected invalid, got: %d", c.Key())
+	}
+	if c.SeekLE(-1) {
+		t.Errorf("cursor.SeekLE: expected invalid, got: %d", c.Key())
+	}
+	if c.Next() || c.Prev() {
+		t.Error("cursor: invalid cursor moved")
+	}
+}
+
+func TestCursorStale(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestCursorStale")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+
+	c := tree.Cursor()
+	c.SeekGE(50)
+	tree.Delete(51)
+	func() {
+		defer func() {
+			if r := recover(); r != ErrModified {
+				t.Errorf("cursor.Next: expected panic: %v, got: %v", ErrModified, r)
+			}
+		}()
+		c.Next()
+	}()
+
+	// repositioning makes the cursor usable again
+	if !c.SeekGE(50) || !c.Next() || c.Key() != 52 {
+		t.Errorf("cursor.Next after SeekGE: expected: %d, got: %d", 52, c.Key())
+	}
+	c.Last()
+	tree.Put(1000, 1000)
+	func() {
+		defer func() {
+			if r := recover(); r != ErrModified {
+				t.Errorf("cursor.Prev: expected panic: %v, got: %v", ErrModified, r)
+			}
+		}()
+		c.Prev()
+	}()
+}
@@ -0,0 +1,66 @@
+package rbtree
+
+// DeleteRange removes the keys from lo to hi inclusive and returns how many it
+// removed. It splits the tree around lo and hi and joins the outer parts back
+// together, so it takes O(log n) however many keys go, and Rank and Select
+// stay correct.
+func (tree *RBTree[K, V]) DeleteRange(lo K, hi K) int {
+	if tree.IsEmpty() || tree.compare(lo, hi) > 0 {
+		return 0
+	}
+	before := tree.Size()
+	l, lbh, first, rest, restBh := tree.split(tree.root, blackHeight(tree.root), lo)
+	middle, _, last, r, rbh := tree.split(rest, restBh, hi)
+	if first != nil {
+		tree.trace(EventNodeRemoved, first)
+	}
+	tree.traceRemoved(middle)
+	if last != nil {
+		tree.trace(EventNodeRemoved, last)
+	}
+	root, _ := tree.join2(l, lbh, r, rbh)
+	tree.setRoot(root)
+	return before - tree.Size()
+}
+
+// DeleteBefore removes the keys less than key and returns how many it
+// removed, in O(log n).
+func (tree *RBTree[K, V]) DeleteBefore(key K) int {
+	if tree.IsEmpty() {
+		return 0
+	}
+	before := tree.Size()
+	l, _, found, r, rbh := tree.split(tree.root, blackHeight(tree.root), key)
+	tree.traceRemoved(l)
+	if found != nil {
+		r, _ = tree.join3(nil, 0, found, r, rbh)
+	}
+	tree.setRoot(r)
+	return before - tree.Size()
+}
+
+// DeleteAfter removes the keys greater than key and returns how many it
+// removed, in O(log n).
+func (tree *RBTree[K, V]) DeleteAfter(key K) int {
+	if tree.IsEmpty() {
+		return 0
+	}
+	before := tree.Size()
+	l, lbh, found, r, _ := tree.split(tree.root, blackHeight(tree.root), key)
+	tree.traceRemoved(r)
+	if found != nil {
+		l, _ = tree.join3(l, lbh, found, nil, 0)
+	}
+	tree.setRoot(l)
+	return before - tree.Size()
+}
+
+// setRoot makes root, a subtree built by split and join, the tree's root.
+func (tree *RBTree[K, V]) setRoot(root *Node[K, V]) {
+	if isRed(root) {
+		root = tree.mutable(root)
+		root.colour = BLACK
+	}
+	tree.mods++
+	tree.root = root
+}
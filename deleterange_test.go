@@ -0,0 +1,100 @@
+package rbtree
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeleteRange(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestDeleteRange")
+	const n = 1000
+	for _, bounds := range [][2]int{{-10, -1}, {-10, 0}, {0, 0}, {1, 1}, {3, 17}, {500, 998}, {0, 2 * n}, {-5, 3000}, {10, 5}} {
+		tree := NewOrdered[int, int]()
+		for i := 0; i < 2*n; i += 2 {
+			tree.Put(i, i)
+		}
+		snapshot := tree.Snapshot()
+
+		lo, hi := bounds[0], bounds[1]
+		expected := len(tree.KeysInRange(lo, hi))
+		removed := tree.DeleteRange(lo, hi)
+		if removed != expected || tree.Size() != n-expected {
+			t.Errorf("tree.DeleteRange(%d, %d): expected: %d removed, got: %d, size %d", lo, hi, expected, removed, tree.Size())
+		}
+		assertValid(t, "DeleteRange", tree)
+		if lo <= hi && len(tree.KeysInRange(lo, hi)) != 0 {
+			t.Errorf("tree.DeleteRange(%d, %d): keys left in range", lo, hi)
+		}
+		for i, key := range tree.Keys() {
+			if tree.Rank(key) != i {
+				t.Errorf("tree.DeleteRange(%d, %d): rank of %d expected: %d, got: %d", lo, hi, key, i, tree.Rank(key))
+				break
+			}
+			if selected, _ := tree.Select(i); selected != key {
+				t.Errorf("tree.DeleteRange(%d, %d): select %d expected: %d, got: %d", lo, hi, i, key, selected)
+				break
+			}
+		}
+		if snapshot.Size() != n {
+			t.Errorf("tree.DeleteRange(%d, %d): snapshot changed", lo, hi)
+		}
+		tree.Put(lo, lo)
+		assertValid(t, "Put after DeleteRange", tree)
+	}
+}
+
+func TestDeleteBeforeAfter(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestDeleteBeforeAfter")
+	const n = 1000
+	for _, key := range []int{-1, 0, 1, 2, 501, 1000, 1998, 1999, 5000} {
+		tree := NewOrdered[int, int]()
+		for i := 0; i < 2*n; i += 2 {
+			tree.Put(i, i)
+		}
+		expected := tree.Rank(key)
+		if removed := tree.DeleteBefore(key); removed != expected {
+			t.Errorf("tree.DeleteBefore(%d): expected: %d, got: %d", key, expected, removed)
+		}
+		assertValid(t, "DeleteBefore", tree)
+		if min, ok := tree.Min(); ok && min < key {
+			t.Errorf("tree.DeleteBefore(%d): min %d", key, min)
+		}
+
+		expected = tree.Size() - tree.Rank(key)
+		if tree.Contains(key) {
+			expected--
+		}
+		if removed := tree.DeleteAfter(key); removed != expected {
+			t.Errorf("tree.DeleteAfter(%d): expected: %d, got: %d", key, expected, removed)
+		}
+		assertValid(t, "DeleteAfter", tree)
+		if max, ok := tree.Max(); ok && max > key {
+			t.Errorf("tree.DeleteAfter(%d): max %d", key, max)
+		}
+		if tree.Size() > 1 || tree.Size() == 1 && !tree.Contains(key) {
+			t.Errorf("tree.DeleteAfter(%d): left %v", key, tree.Keys())
+		}
+	}
+}
+
+func TestAtomicDeleteRange(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestAtomicDeleteRange")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+	a := NewAtomic(tree)
+	before := a.Snapshot()
+	if removed := a.DeleteRange(10, 19); removed != 10 {
+		t.Errorf("AtomicRBTree.DeleteRange: expected: 10, got: %d", removed)
+	}
+	if removed := a.DeleteBefore(5); removed != 5 {
+		t.Errorf("AtomicRBTree.DeleteBefore: expected: 5, got: %d", removed)
+	}
+	if removed := a.DeleteAfter(89); removed != 10 {
+		t.Errorf("AtomicRBTree.DeleteAfter: expected: 10, got: %d", removed)
+	}
+	if a.Size() != 75 || before.Size() != 100 || tree.Size() != 100 {
+		t.Errorf("AtomicRBTree.DeleteRange: sizes expected: 75, 100, 100, got: %d, %d, %d", a.Size(), before.Size(), tree.Size())
+	}
+}
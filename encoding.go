@@ -0,0 +1,283 @@
+package rbtree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// A tree is saved as
+//
+//	magic    "RBTR"
+//	version  1 byte
+//	count    uvarint
+//	entries  count times: uvarint key length, key bytes,
+//	                      uvarint value length, value bytes
+//	checksum 4 bytes, big endian CRC-32C of everything before it
+//
+// Entries are written in ascending key order, so loading rebuilds the tree in
+// linear time without any comparisons beyond checking that order.
+
+const (
+	encodingMagic   = "RBTR"
+	encodingVersion = 1
+)
+
+var (
+	// ErrNoCodec is returned by WriteTo and ReadFrom if SetCodecs has not
+	// been called.
+	ErrNoCodec = errors.New("rbtree: no key and value codecs set")
+	// ErrFormat is returned by ReadFrom for input that is not a saved tree,
+	// is truncated or fails its checksum.
+	ErrFormat = errors.New("rbtree: invalid snapshot format")
+	// ErrVersion is returned by ReadFrom for a snapshot written by a newer
+	// format version.
+	ErrVersion = errors.New("rbtree: unsupported snapshot version")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Codec converts keys or values of type T to and from bytes for WriteTo and
+// ReadFrom.
+type Codec[T any] interface {
+	// AppendBinary appends the encoding of v to buf.
+	AppendBinary(buf []byte, v T) ([]byte, error)
+	// DecodeBinary decodes a value from data, which holds exactly the bytes
+	// AppendBinary produced.
+	DecodeBinary(data []byte) (T, error)
+}
+
+// StringCodec encodes strings as their bytes.
+type StringCodec struct{}
+
+func (StringCodec) AppendBinary(buf []byte, v string) ([]byte, error) {
+	return append(buf, v...), nil
+}
+
+func (StringCodec) DecodeBinary(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// IntCodec encodes ints as zig-zag varints.
+type IntCodec struct{}
+
+func (IntCodec) AppendBinary(buf []byte, v int) ([]byte, error) {
+	return binary.AppendVarint(buf, int64(v)), nil
+}
+
+func (IntCodec) DecodeBinary(data []byte) (int, error) {
+	v, n := binary.Varint(data)
+	if n != len(data) {
+		return 0, fmt.Errorf("%w: bad int", ErrFormat)
+	}
+	return int(v), nil
+}
+
+// SetCodecs sets the codecs WriteTo and ReadFrom use for keys and values.
+func (tree *RBTree[K, V]) SetCodecs(keys Codec[K], values Codec[V]) {
+	tree.keyCodec = keys
+	tree.valueCodec = values
+}
+
+// WriteTo writes the tree to w in a versioned, checksummed binary format,
+// returning the number of bytes written.
+func (tree *RBTree[K, V]) WriteTo(w io.Writer) (int64, error) {
+	if tree.keyCodec == nil || tree.valueCodec == nil {
+		return 0, ErrNoCodec
+	}
+	out := &encoder{w: bufio.NewWriter(w), crc: crc32.New(castagnoli)}
+
+	out.write([]byte(encodingMagic))
+	out.write([]byte{encodingVersion})
+	out.uvarint(uint64(tree.Size()))
+
+	var buf []byte
+	var err error
+	for key, value := range tree.All() {
+		if buf, err = tree.keyCodec.AppendBinary(buf[:0], key); err != nil {
+			return out.n, err
+		}
+		out.uvarint(uint64(len(buf)))
+		out.write(buf)
+		if buf, err = tree.valueCodec.AppendBinary(buf[:0], value); err != nil {
+			return out.n, err
+		}
+		out.uvarint(uint64(len(buf)))
+		out.write(buf)
+		if out.err != nil {
+			return out.n, out.err
+		}
+	}
+
+	out.write(binary.BigEndian.AppendUint32(nil, out.crc.Sum32()))
+	if out.err == nil {
+		out.err = out.w.Flush()
+	}
+	return out.n, out.err
+}
+
+// ReadFrom replaces the contents of the tree with a tree read from r, as
+// written by WriteTo, returning the number of bytes read. The tree is rebuilt
+// in linear time. On error the tree is left unchanged.
+//
+// ReadFrom buffers its input, so unless r is a *bufio.Reader it may consume
+// bytes past the end of the tree.
+func (tree *RBTree[K, V]) ReadFrom(r io.Reader) (int64, error) {
+	if tree.keyCodec == nil || tree.valueCodec == nil {
+		return 0, ErrNoCodec
+	}
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	in := &decoder{r: br, crc: crc32.New(castagnoli)}
+
+	header := in.read(len(encodingMagic) + 1)
+	if in.err != nil {
+		return in.n, in.err
+	}
+	if string(header[:len(encodingMagic)]) != encodingMagic {
+		return in.n, fmt.Errorf("%w: bad magic", ErrFormat)
+	}
+	if header[len(encodingMagic)] != encodingVersion {
+		return in.n, fmt.Errorf("%w: %d", ErrVersion, header[len(encodingMagic)])
+	}
+	count := in.uvarint()
+	if in.err != nil {
+		return in.n, in.err
+	}
+
+	// don't trust count for the allocation, a corrupt one could be huge
+	capacity := count
+	if capacity > 1<<16 {
+		capacity = 1 << 16
+	}
+	keys := make([]K, 0, capacity)
+	values := make([]V, 0, capacity)
+	for i := uint64(0); i < count; i++ {
+		key, err := tree.keyCodec.DecodeBinary(in.read(int(in.uvarint())))
+		if in.err != nil {
+			return in.n, in.err
+		}
+		if err != nil {
+			return in.n, err
+		}
+		if len(keys) > 0 && tree.compare(keys[len(keys)-1], key) >= 0 {
+			return in.n, fmt.Errorf("%w: keys out of order at entry %d", ErrFormat, i)
+		}
+		value, err := tree.valueCodec.DecodeBinary(in.read(int(in.uvarint())))
+		if in.err != nil {
+			return in.n, in.err
+		}
+		if err != nil {
+			return in.n, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	sum := in.crc.Sum32()
+	checksum := in.read(4)
+	if in.err != nil {
+		return in.n, in.err
+	}
+	if binary.BigEndian.Uint32(checksum) != sum {
+		return in.n, fmt.Errorf("%w: checksum mismatch", ErrFormat)
+	}
+
+	tree.mods++
+	tree.root = tree.build(keys, values)
+	return in.n, nil
+}
+
+// encoder writes to w, counting bytes and checksumming them. After the first
+// error it does nothing.
+type encoder struct {
+	w   *bufio.Writer
+	crc hash.Hash32
+	n   int64
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) write(p []byte) {
+	if e.err != nil {
+		return
+	}
+	var n int
+	n, e.err = e.w.Write(p)
+	e.n += int64(n)
+	e.crc.Write(p[:n])
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.write(binary.AppendUvarint(e.buf[:0], v))
+}
+
+// decoder reads from r, counting bytes and checksumming them. After the first
+// error it returns zero values.
+type decoder struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	n   int64
+	err error
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 {
+		d.err = fmt.Errorf("%w: bad length", ErrFormat)
+		return nil
+	}
+	// grow as data arrives rather than trusting n for the allocation
+	var p []byte
+	for len(p) < n && d.err == nil {
+		size := n - len(p)
+		if size > 1<<16 {
+			size = 1 << 16
+		}
+		chunk := make([]byte, size)
+		var m int
+		m, d.err = io.ReadFull(d.r, chunk)
+		p = append(p, chunk[:m]...)
+	}
+	d.n += int64(len(p))
+	d.crc.Write(p)
+	if d.err != nil {
+		d.err = fmt.Errorf("%w: %v", ErrFormat, d.err)
+		return nil
+	}
+	return p
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(byteCounter{d})
+	if err != nil {
+		d.err = fmt.Errorf("%w: %v", ErrFormat, err)
+		return 0
+	}
+	return v
+}
+
+// byteCounter lets binary.ReadUvarint read through a decoder.
+type byteCounter struct {
+	d *decoder
+}
+
+func (b byteCounter) ReadByte() (byte, error) {
+	c, err := b.d.r.ReadByte()
+	if err == nil {
+		b.d.n++
+		b.d.crc.Write([]byte{c})
+	}
+	return c, err
+}
@@ -0,0 +1,124 @@
+package rbtree
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWriteToReadFrom(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestWriteToReadFrom")
+	tree := NewOrdered[int, string]()
+	tree.SetCodecs(IntCodec{}, StringCodec{})
+	for i := -maxKeys / 2; i < maxKeys/2; i++ {
+		tree.Put(i, strconv.Itoa(i))
+	}
+
+	var buf bytes.Buffer
+	written, err := tree.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("tree.WriteTo: %v", err)
+	}
+	if written != int64(buf.Len()) {
+		t.Errorf("tree.WriteTo: expected: %d bytes, got: %d", buf.Len(), written)
+	}
+
+	loaded := NewOrdered[int, string]()
+	loaded.SetCodecs(IntCodec{}, StringCodec{})
+	read, err := loaded.ReadFrom(&buf)
+	if err != nil {
+		t.Fatalf("tree.ReadFrom: %v", err)
+	}
+	if read != written {
+		t.Errorf("tree.ReadFrom: expected: %d bytes, got: %d", written, read)
+	}
+
+	if loaded.Size() != tree.Size() {
+		t.Errorf("loaded.Size(), expected: %d, got: %d", tree.Size(), loaded.Size())
+	}
+	for i := -maxKeys / 2; i < maxKeys/2; i++ {
+		if value, ok := loaded.Get(i); !ok || value != strconv.Itoa(i) {
+			t.Errorf("loaded.Get: expected: %d, got: %s", i, value)
+		}
+		if rank := loaded.Rank(i); rank != i+maxKeys/2 {
+			t.Errorf("loaded.Rank: expected: %d, got: %d", i+maxKeys/2, rank)
+		}
+	}
+	maxHeight := 2 * math.Ilogb(float64(maxKeys+1))
+	if loaded.Height() > maxHeight {
+		t.Errorf("loaded.Height(), expected at most: %d, got: %d", maxHeight, loaded.Height())
+	}
+
+	// the rebuilt tree must still balance correctly under updates
+	for i := -maxKeys / 2; i < maxKeys/2; i += 3 {
+		loaded.Delete(i)
+	}
+	for i := maxKeys / 2; i < maxKeys; i++ {
+		loaded.Put(i, strconv.Itoa(i))
+	}
+	if key, _ := loaded.Select(0); key != -maxKeys/2+1 {
+		t.Errorf("loaded.Select: expected: %d, got: %d", -maxKeys/2+1, key)
+	}
+}
+
+func TestReadFromSizes(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestReadFromSizes")
+	for n := 0; n < 200; n++ {
+		tree := NewOrdered[int, int]()
+		tree.SetCodecs(IntCodec{}, IntCodec{})
+		for i := 0; i < n; i++ {
+			tree.Put(i, i)
+		}
+		var buf bytes.Buffer
+		if _, err := tree.WriteTo(&buf); err != nil {
+			t.Fatalf("tree.WriteTo: %v", err)
+		}
+		if _, err := tree.ReadFrom(&buf); err != nil {
+			t.Fatalf("tree.ReadFrom: %v", err)
+		}
+		if keys := tree.Keys(); len(keys) != n {
+			t.Errorf("tree.Keys, size invalid, expected: %d, got: %d", n, len(keys))
+		}
+		for i := 0; i < n; i++ {
+			if key, _ := tree.Select(i); key != i {
+				t.Errorf("tree.Select: expected: %d, got: %d", i, key)
+			}
+		}
+	}
+}
+
+func TestReadFromErrors(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestReadFromErrors")
+	tree := NewOrdered[int, string]()
+	if _, err := tree.WriteTo(&bytes.Buffer{}); !errors.Is(err, ErrNoCodec) {
+		t.Errorf("tree.WriteTo: expected: %v, got: %v", ErrNoCodec, err)
+	}
+	tree.SetCodecs(IntCodec{}, StringCodec{})
+	for i := 0; i < 100; i++ {
+		tree.Put(i, strconv.Itoa(i))
+	}
+	var buf bytes.Buffer
+	tree.WriteTo(&buf)
+	good := buf.Bytes()
+
+	corrupt := append([]byte(nil), good...)
+	corrupt[len(corrupt)/2] ^= 0xff
+	if _, err := tree.ReadFrom(bytes.NewReader(corrupt)); !errors.Is(err, ErrFormat) {
+		t.Errorf("tree.ReadFrom corrupt: expected: %v, got: %v", ErrFormat, err)
+	}
+	if _, err := tree.ReadFrom(bytes.NewReader(good[:len(good)-1])); !errors.Is(err, ErrFormat) {
+		t.Errorf("tree.ReadFrom truncated: expected: %v, got: %v", ErrFormat, err)
+	}
+	newer := append([]byte(nil), good...)
+	newer[len(encodingMagic)] = encodingVersion + 1
+	if _, err := tree.ReadFrom(bytes.NewReader(newer)); !errors.Is(err, ErrVersion) {
+		t.Errorf("tree.ReadFrom newer: expected: %v, got: %v", ErrVersion, err)
+	}
+
+	if tree.Size() != 100 {
+		t.Errorf("tree.Size(), expected unchanged: %d, got: %d", 100, tree.Size())
+	}
+}
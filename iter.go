@@ -0,0 +1,146 @@
+package rbtree
+
+import (
+	"errors"
+	"iter"
+)
+
+// ErrModified is the value the iterators and cursors panic with when the tree
+// changed under them.
+var ErrModified = errors.New("rbtree: tree modified during iteration")
+
+// All returns an iterator over the keys and values in the tree, in ascending
+// key order. The iterators only read the tree, so the loop body must not
+// modify it: the loop panics with ErrModified on the next step if it did. To
+// write while iterating, range over a Snapshot.
+func (tree *RBTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		ascend(tree.root, tree.guard(yield))
+	}
+}
+
+// Backward returns an iterator over the keys and values in the tree, in
+// descending key order.
+func (tree *RBTree[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		descend(tree.root, tree.guard(yield))
+	}
+}
+
+// Range returns an iterator over the keys in [lo, hi] and their values, in
+// ascending key order.
+func (tree *RBTree[K, V]) Range(lo K, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree.compare(lo, hi) > 0 {
+			return
+		}
+		tree.ascendRange(tree.root, lo, hi, tree.guard(yield))
+	}
+}
+
+// RangeDesc returns an iterator over the keys in [lo, hi] and their values,
+// in descending key order. Note hi comes first.
+func (tree *RBTree[K, V]) RangeDesc(hi K, lo K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tree.compare(lo, hi) > 0 {
+			return
+		}
+		tree.descendRange(tree.root, hi, lo, tree.guard(yield))
+	}
+}
+
+// Limit returns an iterator over the first n pairs of seq, or all of them if
+// n is not positive, such as the latest n keys with Limit(tree.Backward(), n).
+func Limit[K, V any](seq iter.Seq2[K, V], n int) iter.Seq2[K, V] {
+	if n <= 0 {
+		return seq
+	}
+	return func(yield func(K, V) bool) {
+		count := 0
+		for key, value := range seq {
+			if !yield(key, value) {
+				return
+			}
+			count++
+			if count == n {
+				return
+			}
+		}
+	}
+}
+
+// guard wraps yield to panic with ErrModified if the loop body changed the
+// tree and then asked for more.
+func (tree *RBTree[K, V]) guard(yield func(K, V) bool) func(K, V) bool {
+	mods := tree.mods
+	return func(key K, value V) bool {
+		if !yield(key, value) {
+			return false
+		}
+		if tree.mods != mods {
+			panic(ErrModified)
+		}
+		return true
+	}
+}
+
+// ascend, descend and friends return false once yield has asked to stop, so
+// the walk unwinds without visiting anything else.
+
+func ascend[K, V any](node *Node[K, V], yield func(K, V) bool) bool {
+	if node == nil {
+		return true
+	}
+	return ascend(node.left, yield) && yield(node.key, node.value) && ascend(node.right, yield)
+}
+
+func descend[K, V any](node *Node[K, V], yield func(K, V) bool) bool {
+	if node == nil {
+		return true
+	}
+	return descend(node.right, yield) && yield(node.key, node.value) && descend(node.left, yield)
+}
+
+func (tree *RBTree[K, V]) ascendRange(node *Node[K, V], lo K, hi K, yield func(K, V) bool) bool {
+	if node == nil {
+		return true
+	}
+	cmplo := tree.compare(lo, node.key)
+	cmphi := tree.compare(hi, node.key)
+	if cmplo < 0 {
+		if !tree.ascendRange(node.left, lo, hi, yield) {
+			return false
+		}
+	}
+	if cmplo <= 0 && cmphi >= 0 {
+		if !yield(node.key, node.value) {
+			return false
+		}
+	}
+	if cmphi > 0 {
+		return tree.ascendRange(node.right, lo, hi, yield)
+	}
+	return true
+}
+
+func (tree *RBTree[K, V]) descendRange(node *Node[K, V], hi K, lo K, yield func(K, V) bool) bool {
+	if node == nil {
+		return true
+	}
+	cmplo := tree.compare(lo, node.key)
+	cmphi := tree.compare(hi, node.key)
+	if cmphi > 0 {
+		if !tree.descendRange(node.right, hi, lo, yield) {
+			return false
+		}
+	}
+	if cmplo <= 0 && cmphi >= 0 {
+		if !yield(node.key, node.value) {
+			return false
+		}
+	}
+	if cmplo < 0 {
+		return tree.descendRange(node.left, hi, lo, yield)
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package rbtree
+
+import (
+	"iter"
+	"testing"
+	"time"
+)
+
+func TestAll(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestAll")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i*10)
+	}
+
+	var count int = 0
+	for key, value := range tree.All() {
+		if key != count || value != count*10 {
+			t.Errorf("tree.All: expected: %d, got: %d", count, key)
+		}
+		count++
+	}
+	if count != maxKeys {
+		t.Errorf("tree.All, count wrong, expected: %d, got: %d", maxKeys, count)
+	}
+}
+
+func TestBackward(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestBackward")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	var expected int = maxKeys - 1
+	for key := range tree.Backward() {
+		if key != expected {
+			t.Errorf("tree.Backward: expected: %d, got: %d", expected, key)
+		}
+		expected--
+	}
+	if expected != -1 {
+		t.Errorf("tree.Backward, stopped early at: %d", expected)
+	}
+}
+
+func TestRangeBreak(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestRangeBreak")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i += 2 {
+		tree.Put(i, i)
+	}
+
+	var count int = 0
+	var sum int = 0
+	for key := range tree.Range(2000, 3000) {
+		count++
+		sum += key
+		if count == 101 {
+			break
+		}
+	}
+	if count != 101 {
+		t.Errorf("tree.Range, count wrong, expected: %d, got: %d", 101, count)
+	}
+	if sum != 101*2100 {
+		t.Errorf("tree.Range, sum wrong, expected: %d, got: %d", 101*2100, sum)
+	}
+}
+
+func TestRangeDesc(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestRangeDesc")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	var expected int = 3000
+	for key := range tree.RangeDesc(3000, 2000) {
+		if key != expected {
+			t.Errorf("tree.RangeDesc: expected: %d, got: %d", expected, key)
+		}
+		expected--
+	}
+	if expected != 1999 {
+		t.Errorf("tree.RangeDesc, stopped early at: %d", expected)
+	}
+
+	for key := range tree.RangeDesc(2000, 3000) {
+		t.Errorf("tree.RangeDesc: empty range yielded %d", key)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestLimit")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	var expected int = maxKeys - 1
+	for key := range Limit(tree.Backward(), 10) {
+		if key != expected {
+			t.Errorf("Limit: expected: %d, got: %d", expected, key)
+		}
+		expected--
+	}
+	if expected != maxKeys-11 {
+		t.Errorf("Limit, count wrong, expected: %d, got: %d", 10, maxKeys-1-expected)
+	}
+
+	var count int = 0
+	for range Limit(tree.Range(0, 4), 0) {
+		count++
+	}
+	if count != 5 {
+		t.Errorf("Limit, count wrong, expected: %d, got: %d", 5, count)
+	}
+
+	count = 0
+	for range Limit(tree.All(), 10) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("Limit, count wrong after break, expected: %d, got: %d", 3, count)
+	}
+}
+
+func TestSnapshotModifyInLoop(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestSnapshotModifyInLoop")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	var expected int = maxKeys - 1
+	for key := range tree.Snapshot().Backward() {
+		if key != expected {
+			t.Errorf("tree.Backward: expected: %d, got: %d", expected, key)
+		}
+		expected--
+		tree.DeleteMin()
+	}
+	if expected != -1 {
+		t.Errorf("tree.Backward, stopped early at: %d", expected)
+	}
+	if !tree.IsEmpty() {
+		t.Errorf("tree.Size: expected: %d, got: %d", 0, tree.Size())
+	}
+}
+
+func TestModifyInLoop(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestModifyInLoop")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+
+	// changing the tree and then breaking out is allowed
+	for key := range tree.All() {
+		tree.Delete(key)
+		break
+	}
+	if tree.Size() != 99 {
+		t.Errorf("tree.Size: expected: %d, got: %d", 99, tree.Size())
+	}
+
+	seqs := map[string]iter.Seq2[int, int]{
+		"All":       tree.All(),
+		"Backward":  tree.Backward(),
+		"Range":     tree.Range(10, 20),
+		"RangeDesc": tree.RangeDesc(20, 10),
+	}
+	for name, seq := range seqs {
+		func() {
+			defer func() {
+				if r := recover(); r != ErrModified {
+					t.Errorf("tree.%s: expected panic: %v, got: %v", name, ErrModified, r)
+				}
+			}()
+			for key := range seq {
+				tree.Put(key+1000, key)
+			}
+		}()
+	}
+}
+
+func TestRangeDoesNotAllocate(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestRangeDoesNotAllocate")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < 100; i++ {
+		tree.Put(i, i)
+	}
+
+	// scanning must not freeze the tree, or the Put after it copies its path
+	allocs := testing.AllocsPerRun(100, func() {
+		for range tree.Range(10, 12) {
+		}
+		tree.Put(11, 11)
+	})
+	if allocs != 0 {
+		t.Errorf("tree.Range then tree.Put: expected: %d allocations, got: %v", 0, allocs)
+	}
+}
@@ -0,0 +1,183 @@
+package rbtree
+
+// Split and Join work on black heights: the number of black nodes on any path
+// from a node down to a nil link, counting the node itself if it is black.
+// Joining two trees around a key walks down the spine of the taller one until
+// the black heights match, hangs the key there as a red node and rebalances on
+// the way back up, exactly as put does for a new leaf. That costs O(log n),
+// and splitting is a sequence of joins whose costs telescope to O(log n).
+//
+// None of these modify their inputs: they run in a fresh generation and copy
+// the nodes they change, sharing the rest.
+
+// Split returns two trees, left holding the keys less than key and right
+// holding the keys greater than or equal to key, in O(log n). The tree itself
+// is unchanged.
+func (tree *RBTree[K, V]) Split(key K) (left, right *RBTree[K, V]) {
+	tree.freeze()
+	work := tree.clone()
+	l, _, found, r, rbh := work.split(work.root, blackHeight(work.root), key)
+	if found != nil {
+		r, _ = work.join3(nil, 0, found, r, rbh)
+	}
+	if isRed(l) {
+		l = work.mutable(l)
+		l.colour = BLACK
+	}
+	if isRed(r) {
+		r = work.mutable(r)
+		r.colour = BLACK
+	}
+	// left and right share work's generation, which is safe because no node
+	// of that generation is reachable from both.
+	left = work
+	left.root = l
+	right = work.clone()
+	right.gen = genOf(work.gen.Load())
+	right.root = r
+	return left, right
+}
+
+// Join3 returns a tree holding the keys of left, key and the keys of right, in
+// O(log n). Every key in left must be less than key, which must be less than
+// every key in right, otherwise Join3 panics. The result is ordered by left's
+// comparator. left and right are unchanged.
+func Join3[K, V any](left *RBTree[K, V], key K, value V, right *RBTree[K, V]) *RBTree[K, V] {
+	if max, ok := left.Max(); ok && left.compare(max, key) >= 0 {
+		panic("rbtree: Join3 key is not greater than every key in left")
+	}
+	if min, ok := right.Min(); ok && left.compare(key, min) >= 0 {
+		panic("rbtree: Join3 key is not less than every key in right")
+	}
+	left.freeze()
+	right.freeze()
+	result := left.clone()
+	mid := result.newNode(key, value, BLACK)
+	result.root, _ = result.join3(left.root, blackHeight(left.root), mid, right.root, blackHeight(right.root))
+	result.traceKey(EventNodeCreated, key)
+	return result
+}
+
+// Join returns a tree holding the keys of left and right, in O(log n). Every
+// key in left must be less than every key in right, otherwise Join panics.
+// The result is ordered by left's comparator. left and right are unchanged.
+func Join[K, V any](left *RBTree[K, V], right *RBTree[K, V]) *RBTree[K, V] {
+	if max, ok := left.Max(); ok {
+		if min, ok := right.Min(); ok && left.compare(max, min) >= 0 {
+			panic("rbtree: Join keys in left are not less than every key in right")
+		}
+	}
+	result := setWork(left, right)
+	root, _ := result.join2(left.root, blackHeight(left.root), right.root, blackHeight(right.root))
+	result.setRoot(root)
+	return result
+}
+
+func blackHeight[K, V any](node *Node[K, V]) int {
+	bh := 0
+	for ; node != nil; node = node.left {
+		if !isRed(node) {
+			bh++
+		}
+	}
+	return bh
+}
+
+// childBlackHeight returns the black height of node's children, given node's.
+func childBlackHeight[K, V any](node *Node[K, V], bh int) int {
+	if isRed(node) {
+		return bh
+	}
+	return bh - 1
+}
+
+// split divides the subtree rooted at node, whose black height is bh, into the
+// keys less than key and those greater, returning each with its black height
+// and the node holding key if there is one.
+func (tree *RBTree[K, V]) split(node *Node[K, V], bh int, key K) (*Node[K, V], int, *Node[K, V], *Node[K, V], int) {
+	if node == nil {
+		return nil, 0, nil, nil, 0
+	}
+	childBh := childBlackHeight(node, bh)
+	cmp := tree.compare(key, node.key)
+	if cmp < 0 {
+		l, lbh, found, r, rbh := tree.split(node.left, childBh, key)
+		r, rbh = tree.join3(r, rbh, node, node.right, childBh)
+		return l, lbh, found, r, rbh
+	} else if cmp > 0 {
+		l, lbh, found, r, rbh := tree.split(node.right, childBh, key)
+		l, lbh = tree.join3(node.left, childBh, node, l, lbh)
+		return l, lbh, found, r, rbh
+	} else {
+		return node.left, childBh, node, node.right, childBh
+	}
+}
+
+// join3 returns a subtree holding the keys of l, mid's key and the keys of r,
+// and its black height. lbh and rbh are the black heights of l and r. mid's
+// children are replaced, and mid itself is reused rather than copied if it is
+// already in tree's generation.
+func (tree *RBTree[K, V]) join3(l *Node[K, V], lbh int, mid *Node[K, V], r *Node[K, V], rbh int) (*Node[K, V], int) {
+	if isRed(l) {
+		l = tree.mutable(l)
+		l.colour = BLACK
+		lbh++
+	}
+	if isRed(r) {
+		r = tree.mutable(r)
+		r.colour = BLACK
+		rbh++
+	}
+
+	var root *Node[K, V]
+	var bh int
+	if lbh == rbh {
+		root = tree.mutable(mid)
+		root.colour = BLACK
+		root.left = l
+		root.right = r
+		resize(root)
+		return root, lbh + 1
+	} else if lbh > rbh {
+		root, bh = tree.joinRight(l, lbh, mid, r, rbh), lbh
+	} else {
+		root, bh = tree.joinLeft(r, rbh, mid, l, lbh), rbh
+	}
+	if isRed(root) {
+		root.colour = BLACK
+		bh++
+	}
+	return root, bh
+}
+
+// joinRight hangs mid and r off the right spine of node, whose black height
+// is bh and greater than rbh.
+func (tree *RBTree[K, V]) joinRight(node *Node[K, V], bh int, mid *Node[K, V], r *Node[K, V], rbh int) *Node[K, V] {
+	if !isRed(node) && bh == rbh {
+		x := tree.mutable(mid)
+		x.colour = RED
+		x.left = node
+		x.right = r
+		resize(x)
+		return x
+	}
+	node = tree.mutable(node)
+	node.right = tree.joinRight(node.right, childBlackHeight(node, bh), mid, r, rbh)
+	return tree.fixUp(node)
+}
+
+// joinLeft hangs l and mid off the left spine of node, whose black height is
+// bh and greater than lbh.
+func (tree *RBTree[K, V]) joinLeft(node *Node[K, V], bh int, mid *Node[K, V], l *Node[K, V], lbh int) *Node[K, V] {
+	if !isRed(node) && bh == lbh {
+		x := tree.mutable(mid)
+		x.colour = RED
+		x.left = l
+		x.right = node
+		resize(x)
+		return x
+	}
+	node = tree.mutable(node)
+	node.left = tree.joinLeft(node.left, childBlackHeight(node, bh), mid, l, lbh)
+	return tree.fixUp(node)
+}
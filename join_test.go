@@ -0,0 +1,157 @@
+package rbtree
+
+import (
+	"testing"
+	"time"
+)
+
+// assertValid fails the test if tree.Check reports a violation.
+func assertValid[K, V any](t *testing.T, name string, tree *RBTree[K, V]) {
+	t.Helper()
+	if err := tree.Check(); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestSplit")
+	const n = 1000
+	tree := NewOrdered[int, int]()
+	for i := 0; i < n; i += 2 {
+		tree.Put(i, i)
+	}
+
+	for pivot := -1; pivot <= n+1; pivot++ {
+		left, right := tree.Split(pivot)
+		assertValid(t, "left", left)
+		assertValid(t, "right", right)
+
+		expected := (pivot + 1) / 2
+		if pivot < 0 {
+			expected = 0
+		} else if pivot > n {
+			expected = n / 2
+		}
+		if left.Size() != expected || right.Size() != n/2-expected {
+			t.Fatalf("tree.Split(%d): expected sizes %d and %d, got: %d and %d",
+				pivot, expected, n/2-expected, left.Size(), right.Size())
+		}
+		if max, ok := left.Max(); ok && max >= pivot {
+			t.Errorf("tree.Split(%d): left max %d", pivot, max)
+		}
+		if min, ok := right.Min(); ok && min < pivot {
+			t.Errorf("tree.Split(%d): right min %d", pivot, min)
+		}
+		if key, ok := right.Select(0); ok && right.Rank(key) != 0 {
+			t.Errorf("tree.Split(%d): right rank of %d", pivot, key)
+		}
+	}
+
+	// the results are independent of the tree and of each other
+	left, right := tree.Split(n / 2)
+	tree.Put(1, 1)
+	left.Put(3, 3)
+	right.Delete(n / 2)
+	if tree.Size() != n/2+1 || left.Contains(1) || tree.Contains(3) || !tree.Contains(n/2) {
+		t.Error("tree.Split: results share changes with the tree")
+	}
+	assertValid(t, "tree", tree)
+	assertValid(t, "left", left)
+	assertValid(t, "right", right)
+}
+
+func TestJoin(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestJoin")
+	for _, sizes := range [][2]int{{0, 0}, {0, 10}, {10, 0}, {1, 1}, {1, 1000}, {1000, 1}, {300, 700}, {maxKeys, 7}} {
+		left := NewOrdered[int, int]()
+		for i := 0; i < sizes[0]; i++ {
+			left.Put(i, i)
+		}
+		right := NewOrdered[int, int]()
+		for i := 0; i < sizes[1]; i++ {
+			right.Put(sizes[0]+1+i, sizes[0]+1+i)
+		}
+
+		joined := Join3(left, sizes[0], sizes[0], right)
+		assertValid(t, "Join3", joined)
+		if joined.Size() != sizes[0]+sizes[1]+1 {
+			t.Errorf("Join3, size wrong, expected: %d, got: %d", sizes[0]+sizes[1]+1, joined.Size())
+		}
+		for i := 0; i < joined.Size(); i += 7 {
+			if key, _ := joined.Select(i); key != i {
+				t.Errorf("Join3: Select expected: %d, got: %d", i, key)
+			}
+		}
+
+		joined = Join(left, right)
+		assertValid(t, "Join", joined)
+		if joined.Size() != sizes[0]+sizes[1] {
+			t.Errorf("Join, size wrong, expected: %d, got: %d", sizes[0]+sizes[1], joined.Size())
+		}
+		if left.Size() != sizes[0] || right.Size() != sizes[1] {
+			t.Error("Join: inputs changed")
+		}
+		joined.DeleteMin()
+		assertValid(t, "Join", joined)
+		assertValid(t, "left", left)
+		assertValid(t, "right", right)
+	}
+}
+
+func TestJoinTracesAndCounters(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestJoinTracesAndCounters")
+	left := NewOrdered[int, int]()
+	right := NewOrdered[int, int]()
+	for i := 0; i < 100; i++ {
+		left.Put(i, i)
+		right.Put(100+i, i)
+	}
+	events := 0
+	left.SetTracer(TracerFunc[int](func(e Event[int]) {
+		if e.Kind == EventNodeCreated || e.Kind == EventNodeRemoved {
+			events++
+		}
+	}))
+
+	// Join moves keys between trees, it doesn't create or remove any
+	joined := Join(left, right)
+	if events != 0 {
+		t.Errorf("Join: expected: %d node events, got: %d", 0, events)
+	}
+	left.ResetStats()
+	joined.Get(150)
+	if left.Stats().Comparisons == 0 {
+		t.Error("Join: result doesn't share left's counters")
+	}
+
+	// nor does joining with an empty tree start new counters
+	left.ResetStats()
+	Join(left, NewOrdered[int, int]()).Get(50)
+	if left.Stats().Comparisons == 0 {
+		t.Error("Join with empty right: result doesn't share left's counters")
+	}
+}
+
+func TestJoin3Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Join3: expected panic for out of order key")
+		}
+	}()
+	left := NewOrdered[int, int]()
+	left.Put(5, 5)
+	Join3(left, 3, 3, NewOrdered[int, int]())
+}
+
+func TestJoinPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Join: expected panic for overlapping keys")
+		}
+	}()
+	left := NewOrdered[int, int]()
+	left.Put(5, 5)
+	right := NewOrdered[int, int]()
+	right.Put(5, 5)
+	Join(left, right)
+}
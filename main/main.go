@@ -16,38 +16,6 @@ const (
 	trials int = 1000000
 )
 
-type StringKey struct {
-	key   string
-	value string
-}
-
-func (this *StringKey) Compare(other rbtree.Key) int {
-	otherK := other.(*StringKey)
-	if this.key == otherK.key {
-		return 0
-	} else if this.key < otherK.key {
-		return -1
-	} else {
-		return 1
-	}
-}
-
-type IntKey struct {
-	key   int
-	value int
-}
-
-func (this *IntKey) Compare(other rbtree.Key) int {
-	otherK := other.(*IntKey)
-	if this.key == otherK.key {
-		return 0
-	} else if this.key < otherK.key {
-		return -1
-	} else {
-		return 1
-	}
-}
-
 func logElapsedTime(start time.Time, name string) {
 	elapsed := time.Since(start)
 	log.Printf("%s took %s", name, elapsed)
@@ -168,28 +136,26 @@ func TestDeleteIntKeys() {
 
 	f := func() {
 		defer logElapsedTime(time.Now(), "TestDeleteIntKeys")
-		tree := rbtree.NewRBTree()
+		tree := rbtree.NewOrdered[int, int]()
 
 		for i := 0; i < trials; i++ {
 
-			tree.Put(&IntKey{key: i, value: i})
+			tree.Put(i, i)
 
-			key, err := tree.Get(&IntKey{key: i}).(*IntKey)
-			if !err {
-				log.Println(err)
+			value, ok := tree.Get(i)
+			if !ok {
+				log.Println(i)
 			}
-			if key.value != i {
-				log.Println(key.value)
+			if value != i {
+				log.Println(value)
 			}
 		}
 		for i := 0; i < trials; i++ {
 
-			tree.Delete(&IntKey{key: i})
-			key := tree.Get(&IntKey{key: i})
-			if key != nil {
-				//key, err := .(*IntKey)
-				//if !err {
-				log.Println(key)
+			tree.Delete(i)
+			value, ok := tree.Get(i)
+			if ok {
+				log.Println(value)
 			}
 			// if key != nil {
 			// 	t.Error(key.value)
@@ -0,0 +1,334 @@
+// Package metrics exports the size, shape, operation counts and operation
+// latencies of trees through expvar and in the Prometheus text format, using
+// only the standard library.
+//
+// Wrap a ConcurrentRBTree with New and use the returned Tree in its place:
+//
+//	users := metrics.New("users", rbtree.NewConcurrent(rbtree.NewOrdered[string, User]()))
+//	go http.ListenAndServe("localhost:9100", metrics.NewServeMux())
+//
+// The figures for every tree are published as the expvar variable "rbtree",
+// served at /debug/vars, and in Prometheus format at /metrics. Comparisons are
+// not counted for trees with CountComparisons turned off.
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rbtree "github.com/trpedersen/algorithms/rbtree"
+)
+
+// buckets are the upper bounds, in seconds, of the latency histograms.
+var buckets = [...]float64{1e-7, 2.5e-7, 5e-7, 1e-6, 2.5e-6, 5e-6, 1e-5, 2.5e-5, 5e-5, 1e-4, 1e-3, 1e-2}
+
+type op int
+
+const (
+	opPut op = iota
+	opGet
+	opContains
+	opDelete
+	opDeleteMin
+	opDeleteMax
+	opMin
+	opMax
+	opFloor
+	opCeiling
+	opRank
+	opSelect
+	ops
+)
+
+var opNames = [ops]string{
+	opPut:       "put",
+	opGet:       "get",
+	opContains:  "contains",
+	opDelete:    "delete",
+	opDeleteMin: "delete_min",
+	opDeleteMax: "delete_max",
+	opMin:       "min",
+	opMax:       "max",
+	opFloor:     "floor",
+	opCeiling:   "ceiling",
+	opRank:      "rank",
+	opSelect:    "select",
+}
+
+// histogram counts durations into buckets. The last count is for durations
+// above every bucket.
+type histogram struct {
+	counts [len(buckets) + 1]atomic.Uint64
+	nanos  atomic.Uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	h.counts[sort.SearchFloat64s(buckets[:], d.Seconds())].Add(1)
+	h.nanos.Add(uint64(d))
+}
+
+// Tree is a ConcurrentRBTree that times its queries and updates. Methods not
+// listed here, such as Read, Write and the scans, are passed through untimed.
+type Tree[K, V any] struct {
+	*rbtree.ConcurrentRBTree[K, V]
+	latency [ops]histogram
+}
+
+// source is what the registry needs from a Tree, whatever its key and value
+// types.
+type source interface {
+	sample() sample
+}
+
+type sample struct {
+	stats   rbtree.Stats
+	latency [ops][len(buckets) + 1]uint64
+	seconds [ops]float64
+}
+
+var (
+	mu      sync.Mutex
+	sources = make(map[string]source)
+)
+
+func init() {
+	expvar.Publish("rbtree", expvar.Func(expvarValue))
+}
+
+// New wraps tree and registers it under name. New panics if name is already
+// registered.
+func New[K, V any](name string, tree *rbtree.ConcurrentRBTree[K, V]) *Tree[K, V] {
+	t := &Tree[K, V]{ConcurrentRBTree: tree}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := sources[name]; ok {
+		panic("metrics: tree " + strconv.Quote(name) + " is already registered")
+	}
+	sources[name] = t
+	return t
+}
+
+// Unregister stops exporting the tree registered under name.
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(sources, name)
+}
+
+func (t *Tree[K, V]) time(o op, start time.Time) {
+	t.latency[o].observe(time.Since(start))
+}
+
+func (t *Tree[K, V]) Put(key K, value V) (K, V, bool) {
+	defer t.time(opPut, time.Now())
+	return t.ConcurrentRBTree.Put(key, value)
+}
+
+func (t *Tree[K, V]) Get(key K) (V, bool) {
+	defer t.time(opGet, time.Now())
+	return t.ConcurrentRBTree.Get(key)
+}
+
+func (t *Tree[K, V]) Contains(key K) bool {
+	defer t.time(opContains, time.Now())
+	return t.ConcurrentRBTree.Contains(key)
+}
+
+func (t *Tree[K, V]) Delete(key K) (K, V, bool) {
+	defer t.time(opDelete, time.Now())
+	return t.ConcurrentRBTree.Delete(key)
+}
+
+func (t *Tree[K, V]) DeleteMin() (K, V, bool) {
+	defer t.time(opDeleteMin, time.Now())
+	return t.ConcurrentRBTree.DeleteMin()
+}
+
+func (t *Tree[K, V]) DeleteMax() (K, V, bool) {
+	defer t.time(opDeleteMax, time.Now())
+	return t.ConcurrentRBTree.DeleteMax()
+}
+
+func (t *Tree[K, V]) Min() (K, bool) {
+	defer t.time(opMin, time.Now())
+	return t.ConcurrentRBTree.Min()
+}
+
+func (t *Tree[K, V]) Max() (K, bool) {
+	defer t.time(opMax, time.Now())
+	return t.ConcurrentRBTree.Max()
+}
+
+func (t *Tree[K, V]) Floor(key K) (K, bool) {
+	defer t.time(opFloor, time.Now())
+	return t.ConcurrentRBTree.Floor(key)
+}
+
+func (t *Tree[K, V]) Ceiling(key K) (K, bool) {
+	defer t.time(opCeiling, time.Now())
+	return t.ConcurrentRBTree.Ceiling(key)
+}
+
+func (t *Tree[K, V]) Rank(key K) int {
+	defer t.time(opRank, time.Now())
+	return t.ConcurrentRBTree.Rank(key)
+}
+
+func (t *Tree[K, V]) Select(k int) (K, bool) {
+	defer t.time(opSelect, time.Now())
+	return t.ConcurrentRBTree.Select(k)
+}
+
+// sample reads the tree's statistics and its latency histograms. Stats walks
+// the whole tree, but a frozen view of it with no lock held, so scrapes don't
+// block writers.
+func (t *Tree[K, V]) sample() sample {
+	s := sample{stats: t.Stats()}
+	for o := range t.latency {
+		for i := range t.latency[o].counts {
+			s.latency[o][i] = t.latency[o].counts[i].Load()
+		}
+		s.seconds[o] = time.Duration(t.latency[o].nanos.Load()).Seconds()
+	}
+	return s
+}
+
+// samples returns a sample of every registered tree, sorted by name.
+func samples() ([]string, []sample) {
+	mu.Lock()
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	registered := make([]source, len(names))
+	for i, name := range names {
+		registered[i] = sources[name]
+	}
+	mu.Unlock()
+
+	taken := make([]sample, len(names))
+	for i, s := range registered {
+		taken[i] = s.sample()
+	}
+	return names, taken
+}
+
+func expvarValue() any {
+	names, taken := samples()
+	trees := make(map[string]any, len(names))
+	for i, name := range names {
+		s := taken[i]
+		operations := make(map[string]any)
+		for o := op(0); o < ops; o++ {
+			count := uint64(0)
+			for _, n := range s.latency[o] {
+				count += n
+			}
+			operations[opNames[o]] = map[string]any{"count": count, "seconds": s.seconds[o]}
+		}
+		trees[name] = map[string]any{
+			"size":         s.stats.Size,
+			"height":       s.stats.Height,
+			"black_height": s.stats.BlackHeight,
+			"red_nodes":    s.stats.RedNodes,
+			"comparisons":  s.stats.Comparisons,
+			"rotations":    s.stats.Rotations,
+			"colour_flips": s.stats.ColourFlips,
+			"operations":   operations,
+		}
+	}
+	return trees
+}
+
+// WritePrometheus writes the metrics of every registered tree to w in the
+// Prometheus text exposition format.
+func WritePrometheus(w io.Writer) error {
+	names, taken := samples()
+	bw := bufio.NewWriter(w)
+
+	gauges := []struct {
+		name, help string
+		value      func(stats rbtree.Stats) int
+	}{
+		{"rbtree_size", "Number of keys in the tree.", func(stats rbtree.Stats) int { return stats.Size }},
+		{"rbtree_height", "Links on the longest path from the root, 0 if empty.", func(stats rbtree.Stats) int { return stats.Height }},
+		{"rbtree_black_height", "Black nodes on every path from the root.", func(stats rbtree.Stats) int { return stats.BlackHeight }},
+		{"rbtree_red_nodes", "Number of red nodes.", func(stats rbtree.Stats) int { return stats.RedNodes }},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		for i, name := range names {
+			fmt.Fprintf(bw, "%s{tree=\"%s\"} %d\n", g.name, escape(name), g.value(taken[i].stats))
+		}
+	}
+
+	counters := []struct {
+		name, help string
+		value      func(stats rbtree.Stats) uint64
+	}{
+		{"rbtree_comparisons_total", "Comparator calls.", func(stats rbtree.Stats) uint64 { return stats.Comparisons }},
+		{"rbtree_rotations_total", "Rotations.", func(stats rbtree.Stats) uint64 { return stats.Rotations }},
+		{"rbtree_colour_flips_total", "Colour flips.", func(stats rbtree.Stats) uint64 { return stats.ColourFlips }},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for i, name := range names {
+			fmt.Fprintf(bw, "%s{tree=\"%s\"} %d\n", c.name, escape(name), c.value(taken[i].stats))
+		}
+	}
+
+	const histogramName = "rbtree_operation_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s Latency of tree operations.\n# TYPE %s histogram\n", histogramName, histogramName)
+	for i, name := range names {
+		s := taken[i]
+		for o := op(0); o < ops; o++ {
+			labels := fmt.Sprintf("tree=\"%s\",op=\"%s\"", escape(name), opNames[o])
+			cumulative := uint64(0)
+			for b, bound := range buckets {
+				cumulative += s.latency[o][b]
+				fmt.Fprintf(bw, "%s_bucket{%s,le=\"%s\"} %d\n", histogramName, labels, formatFloat(bound), cumulative)
+			}
+			cumulative += s.latency[o][len(buckets)]
+			fmt.Fprintf(bw, "%s_bucket{%s,le=\"+Inf\"} %d\n", histogramName, labels, cumulative)
+			fmt.Fprintf(bw, "%s_sum{%s} %s\n", histogramName, labels, formatFloat(s.seconds[o]))
+			fmt.Fprintf(bw, "%s_count{%s} %d\n", histogramName, labels, cumulative)
+		}
+	}
+	return bw.Flush()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// escape escapes a Prometheus label value.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// Handler returns a handler serving WritePrometheus.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WritePrometheus(w)
+	})
+}
+
+// NewServeMux returns a ServeMux serving the Prometheus metrics at /metrics
+// and expvar's variables, including "rbtree", at /debug/vars.
+func NewServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rbtree "github.com/trpedersen/algorithms/rbtree"
+)
+
+func logElapsedTime(start time.Time, name string) {
+	elapsed := time.Since(start)
+	log.Printf("%s took %s", name, elapsed)
+}
+
+func newTree(t *testing.T, name string, n int) *Tree[int, int] {
+	tree := New(name, rbtree.NewConcurrent(rbtree.NewOrdered[int, int]()))
+	t.Cleanup(func() { Unregister(name) })
+	for i := 0; i < n; i++ {
+		tree.Put(i, i)
+	}
+	for i := 0; i < n; i++ {
+		tree.Get(i)
+	}
+	return tree
+}
+
+func TestWritePrometheus(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestWritePrometheus")
+	newTree(t, "first", 100)
+	newTree(t, `quoted "name"`, 0)
+
+	var sb strings.Builder
+	if err := WritePrometheus(&sb); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	text := sb.String()
+	for _, line := range []string{
+		"# TYPE rbtree_size gauge",
+		`rbtree_size{tree="first"} 100`,
+		`rbtree_height{tree="quoted \"name\""} 0`,
+		`rbtree_black_height{tree="first"} `,
+		"# TYPE rbtree_comparisons_total counter",
+		"# TYPE rbtree_operation_duration_seconds histogram",
+		`rbtree_operation_duration_seconds_bucket{tree="first",op="put",le="+Inf"} 100`,
+		`rbtree_operation_duration_seconds_count{tree="first",op="get"} 100`,
+		`rbtree_operation_duration_seconds_count{tree="first",op="delete"} 0`,
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("WritePrometheus: expected %q in:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, `rbtree_comparisons_total{tree="first"} 0`) {
+		t.Error("WritePrometheus: no comparisons counted")
+	}
+}
+
+func TestExpvar(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestExpvar")
+	tree := newTree(t, "expvar", 10)
+	tree.Delete(3)
+
+	var trees map[string]struct {
+		Size       int
+		Height     int
+		Operations map[string]struct {
+			Count   uint64
+			Seconds float64
+		}
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("rbtree").String()), &trees); err != nil {
+		t.Fatalf("expvar: %v", err)
+	}
+	stats := trees["expvar"]
+	if stats.Size != 9 || stats.Operations["put"].Count != 10 || stats.Operations["delete"].Count != 1 {
+		t.Errorf("expvar: got: %+v", stats)
+	}
+}
+
+func TestServeMux(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestServeMux")
+	newTree(t, "served", 5)
+	server := httptest.NewServer(NewServeMux())
+	defer server.Close()
+
+	for path, expected := range map[string]string{
+		"/metrics":    `rbtree_size{tree="served"} 5`,
+		"/debug/vars": `"served"`,
+	} {
+		response, err := server.Client().Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("GET %s: expected %q in:\n%s", path, expected, body)
+		}
+	}
+}
+
+func TestNewDuplicatePanics(t *testing.T) {
+	newTree(t, "duplicate", 0)
+	defer func() {
+		if recover() == nil {
+			t.Error("New: expected panic for duplicate name")
+		}
+	}()
+	New("duplicate", rbtree.NewConcurrent(rbtree.NewOrdered[int, int]()))
+}
@@ -0,0 +1,407 @@
+package rbtree
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The model tests apply the same operations to a tree and to a sorted slice,
+// and after every step compare each query against the slice and run Check.
+// A failing sequence is shrunk to a minimal one before it is reported.
+
+type opKind byte
+
+const (
+	opPut opKind = iota
+	opDelete
+	opDeleteMin
+	opDeleteMax
+	opUpdate
+	opDeleteRange
+	opKinds
+)
+
+// modelKeys bounds the keys used so that deletes often hit.
+const modelKeys = 64
+
+type modelOp struct {
+	kind opKind
+	key  int
+}
+
+func (op modelOp) String() string {
+	switch op.kind {
+	case opPut:
+		return fmt.Sprintf("Put(%d)", op.key)
+	case opDelete:
+		return fmt.Sprintf("Delete(%d)", op.key)
+	case opDeleteMin:
+		return "DeleteMin()"
+	case opDeleteMax:
+		return "DeleteMax()"
+	case opUpdate:
+		return fmt.Sprintf("Update(%d)", op.key)
+	default:
+		return fmt.Sprintf("DeleteRange(%d, %d)", op.key, op.key+3)
+	}
+}
+
+// decodeOps turns fuzz input into operations, two bytes each.
+func decodeOps(data []byte) []modelOp {
+	ops := make([]modelOp, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		ops = append(ops, modelOp{opKind(data[i] % byte(opKinds)), int(data[i+1]) % modelKeys})
+	}
+	return ops
+}
+
+func randomOps(rnd *rand.Rand, n int) []modelOp {
+	ops := make([]modelOp, n)
+	for i := range ops {
+		// favour puts so the tree grows
+		kind := opKind(rnd.Intn(int(opKinds) + 2))
+		if kind >= opKinds {
+			kind = opPut
+		}
+		ops[i] = modelOp{kind, rnd.Intn(modelKeys)}
+	}
+	return ops
+}
+
+// model is the oracle: keys kept sorted, values alongside.
+type model struct {
+	keys   []int
+	values []int
+}
+
+func (m *model) put(key int, value int) {
+	i, found := slices.BinarySearch(m.keys, key)
+	if found {
+		m.values[i] = value
+		return
+	}
+	m.keys = slices.Insert(m.keys, i, key)
+	m.values = slices.Insert(m.values, i, value)
+}
+
+func (m *model) delete(key int) (int, bool) {
+	i, found := slices.BinarySearch(m.keys, key)
+	if !found {
+		return 0, false
+	}
+	value := m.values[i]
+	m.keys = slices.Delete(m.keys, i, i+1)
+	m.values = slices.Delete(m.values, i, i+1)
+	return value, true
+}
+
+func (m *model) get(key int) (int, bool) {
+	i, found := slices.BinarySearch(m.keys, key)
+	if !found {
+		return 0, false
+	}
+	return m.values[i], true
+}
+
+func (m *model) rank(key int) int {
+	return sort.SearchInts(m.keys, key)
+}
+
+func (m *model) floor(key int) (int, bool) {
+	i := sort.SearchInts(m.keys, key+1)
+	if i == 0 {
+		return 0, false
+	}
+	return m.keys[i-1], true
+}
+
+func (m *model) ceiling(key int) (int, bool) {
+	i := sort.SearchInts(m.keys, key)
+	if i == len(m.keys) {
+		return 0, false
+	}
+	return m.keys[i], true
+}
+
+func (m *model) selectKey(k int) (int, bool) {
+	if k < 0 || k >= len(m.keys) {
+		return 0, false
+	}
+	return m.keys[k], true
+}
+
+func (m *model) keysInRange(lo int, hi int) []int {
+	if lo > hi {
+		return []int{}
+	}
+	return m.keys[sort.SearchInts(m.keys, lo):sort.SearchInts(m.keys, hi+1)]
+}
+
+// runModel applies ops to a new tree and a model, returning an error that
+// describes the first step at which they disagree.
+func runModel(ops []modelOp) error {
+	tree := NewOrdered[int, int]()
+	m := &model{}
+	for step, op := range ops {
+		switch op.kind {
+		case opPut:
+			_, oldValue, replaced := tree.Put(op.key, step)
+			expectedValue, expectedReplaced := m.get(op.key)
+			m.put(op.key, step)
+			if oldValue != expectedValue || replaced != expectedReplaced {
+				return fmt.Errorf("step %d %v: returned (%d, %t), expected (%d, %t)",
+					step, op, oldValue, replaced, expectedValue, expectedReplaced)
+			}
+		case opDelete, opDeleteMin, opDeleteMax:
+			var key, value, expectedKey, expectedValue int
+			var ok, expectedOk bool
+			switch op.kind {
+			case opDelete:
+				key, value, ok = tree.Delete(op.key)
+				expectedKey = op.key
+			case opDeleteMin:
+				key, value, ok = tree.DeleteMin()
+				if len(m.keys) > 0 {
+					expectedKey = m.keys[0]
+				}
+			default:
+				key, value, ok = tree.DeleteMax()
+				if len(m.keys) > 0 {
+					expectedKey = m.keys[len(m.keys)-1]
+				}
+			}
+			expectedValue, expectedOk = m.delete(expectedKey)
+			if !expectedOk {
+				expectedKey = 0
+			}
+			if key != expectedKey || value != expectedValue || ok != expectedOk {
+				return fmt.Errorf("step %d %v: returned (%d, %d, %t), expected (%d, %d, %t)",
+					step, op, key, value, ok, expectedKey, expectedValue, expectedOk)
+			}
+		case opUpdate:
+			// what fn does depends on the step, so every action is exercised
+			decide := func(old int, found bool) (int, Action) {
+				if found && step%3 == 0 {
+					return 0, DeleteKey
+				} else if found || step%2 == 0 {
+					return old + step, StoreValue
+				} else {
+					return 0, KeepValue
+				}
+			}
+			value, ok := tree.Update(op.key, decide)
+			expectedValue, expectedOk := m.get(op.key)
+			switch newValue, action := decide(expectedValue, expectedOk); action {
+			case DeleteKey:
+				m.delete(op.key)
+				expectedValue, expectedOk = 0, false
+			case StoreValue:
+				m.put(op.key, newValue)
+				expectedValue, expectedOk = newValue, true
+			}
+			if value != expectedValue || ok != expectedOk {
+				return fmt.Errorf("step %d %v: returned (%d, %t), expected (%d, %t)",
+					step, op, value, ok, expectedValue, expectedOk)
+			}
+		case opDeleteRange:
+			removed := tree.DeleteRange(op.key, op.key+3)
+			expected := len(m.keysInRange(op.key, op.key+3))
+			for key := op.key; key <= op.key+3; key++ {
+				m.delete(key)
+			}
+			if removed != expected {
+				return fmt.Errorf("step %d %v: returned %d, expected %d", step, op, removed, expected)
+			}
+		}
+		if err := compareModel(tree, m, op.key); err != nil {
+			return fmt.Errorf("step %d %v: %w", step, op, err)
+		}
+	}
+	return nil
+}
+
+// compareModel checks the tree's invariants and its answers to queries
+// around probe against the model.
+func compareModel(tree *RBTree[int, int], m *model, probe int) error {
+	if err := tree.Check(); err != nil {
+		return err
+	}
+	if tree.Size() != len(m.keys) {
+		return fmt.Errorf("Size: expected: %d, got: %d", len(m.keys), tree.Size())
+	}
+	if !slices.Equal(tree.Keys(), m.keys) {
+		return fmt.Errorf("Keys: expected: %v, got: %v", m.keys, tree.Keys())
+	}
+	value, ok := tree.Get(probe)
+	expected, expectedOk := m.get(probe)
+	if value != expected || ok != expectedOk {
+		return fmt.Errorf("Get(%d): expected: (%d, %t), got: (%d, %t)", probe, expected, expectedOk, value, ok)
+	}
+	for q := probe - 1; q <= probe+1; q++ {
+		key, ok := tree.Floor(q)
+		expected, expectedOk := m.floor(q)
+		if key != expected || ok != expectedOk {
+			return fmt.Errorf("Floor(%d): expected: (%d, %t), got: (%d, %t)", q, expected, expectedOk, key, ok)
+		}
+		key, ok = tree.Ceiling(q)
+		expected, expectedOk = m.ceiling(q)
+		if key != expected || ok != expectedOk {
+			return fmt.Errorf("Ceiling(%d): expected: (%d, %t), got: (%d, %t)", q, expected, expectedOk, key, ok)
+		}
+		lower, lowerOk := tree.Lower(q)
+		expected, expectedOk = m.floor(q - 1)
+		if lower != expected || lowerOk != expectedOk {
+			return fmt.Errorf("Lower(%d): expected: (%d, %t), got: (%d, %t)", q, expected, expectedOk, lower, lowerOk)
+		}
+		higher, higherOk := tree.Higher(q)
+		expectedHigher, expectedHigherOk := m.ceiling(q + 1)
+		if higher != expectedHigher || higherOk != expectedHigherOk {
+			return fmt.Errorf("Higher(%d): expected: (%d, %t), got: (%d, %t)", q, expectedHigher, expectedHigherOk, higher, higherOk)
+		}
+		lower, lowerOk, higher, higherOk = tree.Neighbours(q)
+		if lower != expected || lowerOk != expectedOk || higher != expectedHigher || higherOk != expectedHigherOk {
+			return fmt.Errorf("Neighbours(%d): expected: (%d, %t, %d, %t), got: (%d, %t, %d, %t)",
+				q, expected, expectedOk, expectedHigher, expectedHigherOk, lower, lowerOk, higher, higherOk)
+		}
+		rank := tree.Rank(q)
+		if rank != m.rank(q) {
+			return fmt.Errorf("Rank(%d): expected: %d, got: %d", q, m.rank(q), rank)
+		}
+		rank, ok = tree.RankFloor(q)
+		if expectedKey, expectedOk := m.floor(q); ok != expectedOk || ok && rank != m.rank(expectedKey) {
+			return fmt.Errorf("RankFloor(%d): expected: (%d, %t), got: (%d, %t)", q, m.rank(expectedKey), expectedOk, rank, ok)
+		}
+		rank, ok = tree.RankCeiling(q)
+		if expectedKey, expectedOk := m.ceiling(q); ok != expectedOk || ok && rank != m.rank(expectedKey) {
+			return fmt.Errorf("RankCeiling(%d): expected: (%d, %t), got: (%d, %t)", q, m.rank(expectedKey), expectedOk, rank, ok)
+		}
+	}
+	for _, k := range []int{-1, 0, m.rank(probe), len(m.keys) - 1, len(m.keys)} {
+		key, ok := tree.Select(k)
+		expected, expectedOk := m.selectKey(k)
+		if key != expected || ok != expectedOk {
+			return fmt.Errorf("Select(%d): expected: (%d, %t), got: (%d, %t)", k, expected, expectedOk, key, ok)
+		}
+	}
+	for _, bounds := range [][2]int{{probe - 3, probe + 3}, {probe, probe}, {probe + 1, probe - 1}} {
+		keys := tree.KeysInRange(bounds[0], bounds[1])
+		expected := m.keysInRange(bounds[0], bounds[1])
+		if !slices.Equal(keys, expected) {
+			return fmt.Errorf("KeysInRange(%d, %d): expected: %v, got: %v", bounds[0], bounds[1], expected, keys)
+		}
+		if count := tree.CountInRange(bounds[0], bounds[1], Inclusive); count != len(expected) {
+			return fmt.Errorf("CountInRange(%d, %d): expected: %d, got: %d", bounds[0], bounds[1], len(expected), count)
+		}
+		exclusive := len(m.keysInRange(bounds[0]+1, bounds[1]-1))
+		if count := tree.CountInRange(bounds[0], bounds[1], Exclusive); count != exclusive {
+			return fmt.Errorf("CountInRange(%d, %d, Exclusive): expected: %d, got: %d", bounds[0], bounds[1], exclusive, count)
+		}
+	}
+	return nil
+}
+
+// shrinkOps returns a smaller sequence for which fails is still true: it
+// removes chunks of operations, halving the chunk size down to one, then
+// lowers keys, repeating until neither makes progress.
+func shrinkOps(ops []modelOp, fails func([]modelOp) bool) []modelOp {
+	for progress := true; progress; {
+		progress = false
+		for chunk := len(ops) / 2; chunk >= 1; chunk /= 2 {
+			for i := 0; i+chunk <= len(ops); {
+				candidate := slices.Concat(ops[:i], ops[i+chunk:])
+				if fails(candidate) {
+					ops = candidate
+					progress = true
+				} else {
+					i += chunk
+				}
+			}
+		}
+		for i := range ops {
+			for _, key := range []int{0, ops[i].key / 2, ops[i].key - 1} {
+				if key < 0 || key >= ops[i].key {
+					continue
+				}
+				candidate := slices.Clone(ops)
+				candidate[i].key = key
+				if fails(candidate) {
+					ops = candidate
+					progress = true
+					break
+				}
+			}
+		}
+	}
+	return ops
+}
+
+// checkModel fails the test with a minimal reproducer if the tree and the
+// model disagree on ops.
+func checkModel(t *testing.T, ops []modelOp) {
+	t.Helper()
+	if runModel(ops) == nil {
+		return
+	}
+	ops = shrinkOps(ops, func(ops []modelOp) bool { return runModel(ops) != nil })
+	steps := make([]string, len(ops))
+	for i, op := range ops {
+		steps[i] = op.String()
+	}
+	t.Fatalf("model: %v\nminimal reproducer: %s", runModel(ops), strings.Join(steps, ", "))
+}
+
+func TestModelRandom(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestModelRandom")
+	for seed := int64(1); seed <= 50; seed++ {
+		checkModel(t, randomOps(rand.New(rand.NewSource(seed)), 1000))
+	}
+}
+
+func TestModelSequential(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestModelSequential")
+	var ops []modelOp
+	for i := 0; i < modelKeys; i++ {
+		ops = append(ops, modelOp{opPut, i})
+	}
+	for i := modelKeys - 1; i >= 0; i -= 2 {
+		ops = append(ops, modelOp{opDelete, i}, modelOp{opDeleteMin, 0}, modelOp{opDeleteMax, 0})
+	}
+	checkModel(t, ops)
+}
+
+func TestShrinkOps(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestShrinkOps")
+	// fails whenever at least two keys of 10 or more are put
+	fails := func(ops []modelOp) bool {
+		puts := 0
+		for _, op := range ops {
+			if op.kind == opPut && op.key >= 10 {
+				puts++
+			}
+		}
+		return puts >= 2
+	}
+	ops := randomOps(rand.New(rand.NewSource(1)), 500)
+	if !fails(ops) {
+		t.Fatal("shrinkOps: random ops do not fail")
+	}
+	shrunk := shrinkOps(ops, fails)
+	expected := []modelOp{{opPut, 10}, {opPut, 10}}
+	if !slices.Equal(shrunk, expected) {
+		t.Errorf("shrinkOps: expected: %v, got: %v", expected, shrunk)
+	}
+}
+
+func FuzzModel(f *testing.F) {
+	f.Add([]byte{0, 1, 0, 2, 0, 3, 1, 2, 2, 0, 3, 0})
+	f.Add([]byte{0, 5, 0, 4, 0, 3, 0, 2, 0, 1, 1, 3, 1, 5, 1, 1})
+	f.Add([]byte{0, 10, 0, 20, 0, 30, 0, 40, 0, 50, 0, 60, 3, 0, 3, 0, 2, 0, 1, 30})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkModel(t, decodeOps(data))
+	})
+}
@@ -1,19 +1,29 @@
 package rbtree
 
 import (
-	"log"
+	"cmp"
+	"sync/atomic"
 )
 
+// Key is implemented by types that define their own ordering. Trees of Key
+// values are created with NewRBTree, which orders them with CompareTo.
 type Key interface {
 	CompareTo(Key) int
 }
 
-type Node struct {
-	key    Key
-	left   *Node
-	right  *Node
+type Node[K, V any] struct {
+	key    K
+	value  V
+	left   *Node[K, V]
+	right  *Node[K, V]
 	colour bool
+	// height is the number of links on the longest path down from the node,
+	// kept alongside N so Height is O(1).
+	height int32
 	N      int
+	// gen is the generation of the tree that created this node; a tree may
+	// only modify nodes of its own generation and copies any others.
+	gen uint64
 }
 
 const (
@@ -21,15 +31,16 @@ const (
 	BLACK bool = false
 )
 
-func NewNode(key Key, colour bool, N int) *Node {
-	return &Node{
+func NewNode[K, V any](key K, value V, colour bool, N int) *Node[K, V] {
+	return &Node[K, V]{
 		key:    key,
+		value:  value,
 		colour: colour,
 		N:      N,
 	}
 }
 
-func isRed(node *Node) bool {
+func isRed[K, V any](node *Node[K, V]) bool {
 	if node == nil {
 		return false
 	} else {
@@ -37,33 +48,149 @@ func isRed(node *Node) bool {
 	}
 }
 
-type RBTree struct {
-	root     *Node
-	compares int
+// RBTree is a left-leaning red-black tree mapping keys of type K to values
+// of type V. Keys are ordered by the tree's comparator, which returns a
+// negative number, zero or a positive number as a is less than, equal to or
+// greater than b.
+//
+// Trees are persistent: Snapshot returns an independent copy in O(1), after
+// which each tree copies only the nodes on the paths it modifies.
+type RBTree[K, V any] struct {
+	root       *Node[K, V]
+	compare    func(a, b K) int
+	gen        *atomic.Uint64
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+	tracer     Tracer[K]
+	counters   *counters
+	counting   bool
+	// mods counts the changes to the tree, so iterators and cursors can
+	// tell when it was modified under them.
+	mods uint64
+	// comparator is the comparator the tree was made with, which compare
+	// wraps to count and trace calls.
+	comparator func(a, b K) int
 }
 
-func NewRBTree() *RBTree {
-	return &RBTree{}
+// generations hands out tree generations. Zero is never used, so nodes made
+// with NewNode belong to no tree.
+var generations atomic.Uint64
+
+func newGen() uint64 {
+	return generations.Add(1)
+}
+
+// genOf returns a tree's generation holder set to gen. It is atomic because
+// freeze may run under ConcurrentRBTree's shared read lock, in Read.
+func genOf(gen uint64) *atomic.Uint64 {
+	holder := new(atomic.Uint64)
+	holder.Store(gen)
+	return holder
+}
+
+// NewOrdered returns an empty tree whose keys are ordered by cmp.Compare.
+func NewOrdered[K cmp.Ordered, V any]() *RBTree[K, V] {
+	return NewWithComparator[K, V](cmp.Compare[K])
+}
+
+// NewWithComparator returns an empty tree whose keys are ordered by compare.
+func NewWithComparator[K, V any](compare func(a, b K) int) *RBTree[K, V] {
+	tree := &RBTree[K, V]{comparator: compare, gen: genOf(newGen()), counters: &counters{}, counting: true}
+	tree.instrument()
+	return tree
+}
+
+// NewRBTree returns an empty tree of Key values, ordered by CompareTo.
+func NewRBTree[V any]() *RBTree[Key, V] {
+	return NewWithComparator[Key, V](Key.CompareTo)
+}
+
+// Snapshot returns a copy of the tree in O(1). The copy and the original
+// share nodes until either is modified, and changes to one are never visible
+// in the other. Nodes only reachable from dropped versions are garbage
+// collected as usual.
+func (tree *RBTree[K, V]) Snapshot() *RBTree[K, V] {
+	tree.freeze()
+	clone := tree.clone()
+	clone.ownCounters()
+	return clone
 }
 
-func (tree *RBTree) Size() int {
+// freeze moves the tree to a new generation, so that it copies rather than
+// modifies the nodes it has now. Call it before sharing those nodes.
+func (tree *RBTree[K, V]) freeze() {
+	tree.gen.Store(newGen())
+}
+
+// clone returns a copy of the tree in a new generation, leaving tree itself
+// untouched. Unlike Snapshot it is only safe if tree is never modified again,
+// since tree still owns its nodes.
+func (tree *RBTree[K, V]) clone() *RBTree[K, V] {
+	clone := *tree
+	clone.gen = genOf(newGen())
+	return &clone
+}
+
+// view freezes the tree and returns a read-only copy of it as it is now. The
+// tree goes on copying the nodes it changes, so the view never changes and a
+// scan can walk it while the tree is written.
+func (tree *RBTree[K, V]) view() *RBTree[K, V] {
+	tree.freeze()
+	return tree.clone()
+}
+
+// mutable returns node if the tree may modify it, otherwise a copy of node
+// that the tree owns. Callers must link the result in place of node.
+func (tree *RBTree[K, V]) mutable(node *Node[K, V]) *Node[K, V] {
+	gen := tree.gen.Load()
+	if node == nil || node.gen == gen {
+		return node
+	}
+	clone := *node
+	clone.gen = gen
+	return &clone
+}
+
+func (tree *RBTree[K, V]) Size() int {
 	return size(tree.root)
 }
 
-func (tree *RBTree) Put(key Key) {
-	tree.root = put(tree.root, key)
+// Put associates value with key. If the tree already held an equal key, Put
+// replaces it and returns the previous key and value, and true.
+func (tree *RBTree[K, V]) Put(key K, value V) (K, V, bool) {
+	var old entry[K, V]
+	tree.mods++
+	tree.root = tree.put(tree.root, key, value, &old)
+	tree.root.colour = BLACK
+	return old.key, old.value, old.found
 }
 
-func (tree *RBTree) Contains(key Key) bool {
-	return tree.Get(key) != nil
+// entry is a key and value that a recursive helper replaced or removed.
+type entry[K, V any] struct {
+	key   K
+	value V
+	found bool
+	// node is the node that held key, which join2 hangs back in.
+	node *Node[K, V]
 }
 
-func (tree *RBTree) IsEmpty() bool {
+func (e *entry[K, V]) set(node *Node[K, V]) {
+	e.node = node
+	e.key = node.key
+	e.value = node.value
+	e.found = true
+}
+
+func (tree *RBTree[K, V]) Contains(key K) bool {
+	_, ok := tree.Get(key)
+	return ok
+}
+
+func (tree *RBTree[K, V]) IsEmpty() bool {
 	return tree.root == nil
 }
 
 func CompareTo(a string, b string) int {
-	//compares += 1
 	if a == b {
 		return 0
 	} else if a < b {
@@ -73,158 +200,191 @@ func CompareTo(a string, b string) int {
 	}
 }
 
-func size(node *Node) int {
+func size[K, V any](node *Node[K, V]) int {
 	if node == nil {
 		return 0
 	}
 	return node.N
 }
 
-func put(node *Node, key Key) *Node {
+func (tree *RBTree[K, V]) put(node *Node[K, V], key K, value V, old *entry[K, V]) *Node[K, V] {
 	// change key's value to value if key in subtree rooted at node
 	// otherwise add a new node to subtree associating key with value.
 	if node == nil {
-		return NewNode(key, RED, 1)
+		return tree.addNode(key, value, RED)
 	}
-	cmp := key.CompareTo(node.key)
+	node = tree.mutable(node)
+	cmp := tree.compare(key, node.key)
 	if cmp < 0 {
-		node.left = put(node.left, key)
+		node.left = tree.put(node.left, key, value, old)
 	} else if cmp > 0 {
-		node.right = put(node.right, key)
+		node.right = tree.put(node.right, key, value, old)
 	} else {
+		old.set(node)
 		node.key = key
+		node.value = value
 	}
 
+	return tree.fixUp(node)
+}
+
+// fixUp restores the left-leaning invariants at node after a red node has been
+// hung beneath it. node must belong to the tree.
+func (tree *RBTree[K, V]) fixUp(node *Node[K, V]) *Node[K, V] {
 	if isRed(node.right) && !isRed(node.left) {
-		node = rotateLeft(node)
+		node = tree.rotateLeft(node)
 	}
 	if isRed(node.left) && isRed(node.left.left) {
-		node = rotateRight(node)
+		node = tree.rotateRight(node)
 	}
 	if isRed(node.left) && isRed(node.right) {
-		flipColours(node)
+		tree.flipColours(node)
 	}
-	node.N = 1 + size(node.left) + size(node.right)
-
+	resize(node)
 	return node
 }
 
-func rotateLeft(h *Node) *Node {
-	x := h.right
+func (tree *RBTree[K, V]) rotateLeft(h *Node[K, V]) *Node[K, V] {
+	tree.trace(EventRotateLeft, h)
+	tree.counters.rotations.Add(1)
+	h = tree.mutable(h)
+	x := tree.mutable(h.right)
 	h.right = x.left
 	x.left = h
 	x.colour = h.colour
 	h.colour = RED
-	x.N = h.N
-	h.N = 1 + size(h.left) + size(h.right)
+	resize(h)
+	resize(x)
 	return x
 }
 
-func rotateRight(h *Node) *Node {
-	if h == nil {
-		log.Println("1 nil h")
-	}
-	x := h.left
-	if x == nil {
-		log.Println("2 nil x")
-	}
-	//clog.Println(x)
+func (tree *RBTree[K, V]) rotateRight(h *Node[K, V]) *Node[K, V] {
+	tree.trace(EventRotateRight, h)
+	tree.counters.rotations.Add(1)
+	h = tree.mutable(h)
+	x := tree.mutable(h.left)
 	h.left = x.right
 	x.right = h
 	x.colour = h.colour
 	h.colour = RED
-	x.N = h.N
-	h.N = 1 + size(h.left) + size(h.right)
-	// rotateRights++
+	resize(h)
+	resize(x)
 	return x
 }
 
-func flipColours(h *Node) {
+// flipColours modifies h in place, so h must already belong to the tree.
+func (tree *RBTree[K, V]) flipColours(h *Node[K, V]) {
+	tree.trace(EventFlipColours, h)
+	tree.counters.flips.Add(1)
+	h.left = tree.mutable(h.left)
+	h.right = tree.mutable(h.right)
 	h.colour = !h.colour
 	h.left.colour = !h.left.colour
 	h.right.colour = !h.right.colour
-	//flipColours++
 }
 
-func (tree *RBTree) Get(key Key) Key {
-	node := get(tree.root, key)
+// Get returns the value associated with key, and whether key was found.
+func (tree *RBTree[K, V]) Get(key K) (V, bool) {
+	node := tree.get(tree.root, key)
 	if node != nil {
-		return node.key
+		return node.value, true
 	} else {
-		return nil
+		var zero V
+		return zero, false
 	}
 }
 
-func get(node *Node, key Key) *Node {
+func (tree *RBTree[K, V]) get(node *Node[K, V], key K) *Node[K, V] {
 	if node == nil {
 		return nil
 	}
-	cmp := key.CompareTo(node.key)
+	cmp := tree.compare(key, node.key)
 	if cmp < 0 {
-		return get(node.left, key)
+		return tree.get(node.left, key)
 	} else if cmp > 0 {
-		return get(node.right, key)
+		return tree.get(node.right, key)
 	} else {
 		return node
 	}
 }
 
-func (tree *RBTree) Delete(key Key) {
-	if !tree.Contains(key) {
-		return
+// Delete removes key from the tree in a single descent, returning the key and
+// value it removed and whether key was found.
+func (tree *RBTree[K, V]) Delete(key K) (K, V, bool) {
+	var removed entry[K, V]
+	if tree.IsEmpty() {
+		return removed.key, removed.value, false
 	}
-	// if both children red, set root black
+	tree.mods++
+	// if both children black, set root red
 	if !isRed(tree.root.left) && !isRed(tree.root.right) {
+		tree.root = tree.mutable(tree.root)
 		tree.root.colour = RED
 	}
-	tree.root = deleteNode(tree.root, key)
+	tree.root = tree.deleteNode(tree.root, key, &removed)
 	if !tree.IsEmpty() {
 		tree.root.colour = BLACK
 	}
+	return removed.key, removed.value, removed.found
 }
 
-func deleteNode(node *Node, key Key) *Node {
+func (tree *RBTree[K, V]) deleteNode(node *Node[K, V], key K, removed *entry[K, V]) *Node[K, V] {
 
 	if node == nil {
 		return nil
 	}
+	node = tree.mutable(node)
 
-	if key.CompareTo(node.key) < 0 {
+	if tree.compare(key, node.key) < 0 {
+		if node.left == nil {
+			// key is not in the tree
+			return tree.balance(node)
+		}
 		if !isRed(node.left) && !isRed(node.left.left) {
-			node = moveRedLeft(node)
+			node = tree.moveRedLeft(node)
 		}
-		node.left = deleteNode(node.left, key)
+		node.left = tree.deleteNode(node.left, key, removed)
 	} else {
 		if isRed(node.left) {
-			node = rotateRight(node)
+			node = tree.rotateRight(node)
 		}
-		if key.CompareTo(node.key) == 0 && (node.right == nil) {
+		if tree.compare(key, node.key) == 0 && (node.right == nil) {
+			tree.trace(EventNodeRemoved, node)
+			removed.set(node)
 			return nil
 		}
+		if node.right == nil {
+			// key is not in the tree
+			return tree.balance(node)
+		}
 		if !isRed(node.right) && !isRed(node.right.left) {
-			node = moveRedRight(node)
+			node = tree.moveRedRight(node)
 		}
-		if key.CompareTo(node.key) == 0 {
-			x := min(node.right)
-			node.key = x.key
-			node.right = deleteMin(node.right)
+		if tree.compare(key, node.key) == 0 {
+			removed.set(node)
+			var successor entry[K, V]
+			node.right = tree.deleteMin(node.right, &successor)
+			tree.traceKey(EventNodeRemoved, successor.key)
+			node.key = successor.key
+			node.value = successor.value
 		} else {
-			node.right = deleteNode(node.right, key)
+			node.right = tree.deleteNode(node.right, key, removed)
 		}
 	}
-	return balance(node)
+	return tree.balance(node)
 }
 
-func (tree *RBTree) Min() Key {
+// Min returns the smallest key in the tree, or false if the tree is empty.
+func (tree *RBTree[K, V]) Min() (K, bool) {
 	if tree.IsEmpty() {
-		return nil
+		var zero K
+		return zero, false
 	} else {
-		return min(tree.root).key
-
+		return min(tree.root).key, true
 	}
 }
 
-func min(node *Node) *Node {
+func min[K, V any](node *Node[K, V]) *Node[K, V] {
 	if node == nil {
 		return nil
 	} else if node.left == nil {
@@ -234,15 +394,17 @@ func min(node *Node) *Node {
 	}
 }
 
-func (tree *RBTree) Max() Key {
+// Max returns the largest key in the tree, or false if the tree is empty.
+func (tree *RBTree[K, V]) Max() (K, bool) {
 	if tree.IsEmpty() {
-		return nil
+		var zero K
+		return zero, false
 	} else {
-		return max(tree.root).key
+		return max(tree.root).key, true
 	}
 }
 
-func max(node *Node) *Node {
+func max[K, V any](node *Node[K, V]) *Node[K, V] {
 	if node == nil {
 		return nil
 	} else if node.right == nil {
@@ -252,26 +414,29 @@ func max(node *Node) *Node {
 	}
 }
 
-func (tree *RBTree) Floor(key Key) Key {
-	node := floor(tree.root, key)
+// Floor returns the largest key less than or equal to key, or false if
+// there is none.
+func (tree *RBTree[K, V]) Floor(key K) (K, bool) {
+	node := tree.floor(tree.root, key)
 	if node == nil {
-		return nil
+		var zero K
+		return zero, false
 	} else {
-		return node.key
+		return node.key, true
 	}
 }
 
-func floor(node *Node, key Key) *Node {
+func (tree *RBTree[K, V]) floor(node *Node[K, V], key K) *Node[K, V] {
 	if node == nil {
 		return nil
 	}
-	cmp := key.CompareTo(node.key)
+	cmp := tree.compare(key, node.key)
 	if cmp == 0 {
 		return node
 	} else if cmp < 0 {
-		return floor(node.left, key)
+		return tree.floor(node.left, key)
 	} else {
-		t := floor(node.right, key)
+		t := tree.floor(node.right, key)
 		if t != nil {
 			return t
 		} else {
@@ -280,26 +445,29 @@ func floor(node *Node, key Key) *Node {
 	}
 }
 
-func (tree *RBTree) Ceiling(key Key) Key {
-	node := ceiling(tree.root, key)
+// Ceiling returns the smallest key greater than or equal to key, or false if
+// there is none.
+func (tree *RBTree[K, V]) Ceiling(key K) (K, bool) {
+	node := tree.ceiling(tree.root, key)
 	if node == nil {
-		return nil
+		var zero K
+		return zero, false
 	} else {
-		return node.key
+		return node.key, true
 	}
 }
 
-func ceiling(node *Node, key Key) *Node {
+func (tree *RBTree[K, V]) ceiling(node *Node[K, V], key K) *Node[K, V] {
 	if node == nil {
 		return nil
 	}
-	cmp := key.CompareTo(node.key)
+	cmp := tree.compare(key, node.key)
 	if cmp == 0 {
 		return node
 	} else if cmp > 0 {
-		return ceiling(node.right, key)
+		return tree.ceiling(node.right, key)
 	} else {
-		t := ceiling(node.left, key)
+		t := tree.ceiling(node.left, key)
 		if t != nil {
 			return t
 		} else {
@@ -308,37 +476,203 @@ func ceiling(node *Node, key Key) *Node {
 	}
 }
 
-func (tree *RBTree) Rank(key Key) int {
-	return rank(tree.root, key)
+// Lower returns the largest key strictly less than key, or false if there is
+// none.
+func (tree *RBTree[K, V]) Lower(key K) (K, bool) {
+	node := tree.lower(tree.root, key)
+	if node == nil {
+		var zero K
+		return zero, false
+	} else {
+		return node.key, true
+	}
 }
 
-func rank(node *Node, key Key) int {
+func (tree *RBTree[K, V]) lower(node *Node[K, V], key K) *Node[K, V] {
 	if node == nil {
-		return 0
+		return nil
+	}
+	cmp := tree.compare(key, node.key)
+	if cmp <= 0 {
+		return tree.lower(node.left, key)
+	} else {
+		t := tree.lower(node.right, key)
+		if t != nil {
+			return t
+		} else {
+			return node
+		}
+	}
+}
+
+// Higher returns the smallest key strictly greater than key, or false if
+// there is none.
+func (tree *RBTree[K, V]) Higher(key K) (K, bool) {
+	node := tree.higher(tree.root, key)
+	if node == nil {
+		var zero K
+		return zero, false
+	} else {
+		return node.key, true
+	}
+}
+
+func (tree *RBTree[K, V]) higher(node *Node[K, V], key K) *Node[K, V] {
+	if node == nil {
+		return nil
 	}
-	cmp := key.CompareTo(node.key)
+	cmp := tree.compare(key, node.key)
+	if cmp >= 0 {
+		return tree.higher(node.right, key)
+	} else {
+		t := tree.higher(node.left, key)
+		if t != nil {
+			return t
+		} else {
+			return node
+		}
+	}
+}
+
+// Predecessor is Lower.
+func (tree *RBTree[K, V]) Predecessor(key K) (K, bool) {
+	return tree.Lower(key)
+}
+
+// Successor is Higher.
+func (tree *RBTree[K, V]) Successor(key K) (K, bool) {
+	return tree.Higher(key)
+}
+
+// Neighbours returns both Lower(key) and Higher(key) from a single descent.
+func (tree *RBTree[K, V]) Neighbours(key K) (lower K, lowerOk bool, higher K, higherOk bool) {
+	// the last nodes at which the search went right and left
+	var lo, hi *Node[K, V]
+	node := tree.root
+	for node != nil {
+		cmp := tree.compare(key, node.key)
+		if cmp < 0 {
+			hi = node
+			node = node.left
+		} else if cmp > 0 {
+			lo = node
+			node = node.right
+		} else {
+			if node.left != nil {
+				lo = max(node.left)
+			}
+			if node.right != nil {
+				hi = min(node.right)
+			}
+			break
+		}
+	}
+	if lo != nil {
+		lower, lowerOk = lo.key, true
+	}
+	if hi != nil {
+		higher, higherOk = hi.key, true
+	}
+	return lower, lowerOk, higher, higherOk
+}
+
+// Rank returns the number of keys less than key.
+func (tree *RBTree[K, V]) Rank(key K) int {
+	rank, _ := tree.rank(tree.root, key)
+	return rank
+}
+
+// rank returns the number of keys less than key in the subtree rooted at
+// node, and whether the subtree holds key.
+func (tree *RBTree[K, V]) rank(node *Node[K, V], key K) (int, bool) {
+	if node == nil {
+		return 0, false
+	}
+	cmp := tree.compare(key, node.key)
 	if cmp == 0 {
-		return size(node.left)
+		return size(node.left), true
 	} else if cmp < 0 {
-		return rank(node.left, key)
+		return tree.rank(node.left, key)
 	} else {
-		return size(node.left) + 1 + rank(node.right, key)
+		rank, found := tree.rank(node.right, key)
+		return size(node.left) + 1 + rank, found
+	}
+}
+
+// RankFloor returns the rank of the largest key less than or equal to key, or
+// false if there is none.
+func (tree *RBTree[K, V]) RankFloor(key K) (int, bool) {
+	rank, found := tree.rank(tree.root, key)
+	if found {
+		return rank, true
+	} else if rank > 0 {
+		return rank - 1, true
+	} else {
+		return 0, false
+	}
+}
+
+// RankCeiling returns the rank of the smallest key greater than or equal to
+// key, or false if there is none.
+func (tree *RBTree[K, V]) RankCeiling(key K) (int, bool) {
+	rank, _ := tree.rank(tree.root, key)
+	if rank < tree.Size() {
+		return rank, true
+	} else {
+		return 0, false
+	}
+}
+
+// Bounds says which ends of a range are excluded. The zero value includes
+// both.
+type Bounds int
+
+const (
+	// ExcludeLo leaves lo out of the range, and ExcludeHi leaves out hi.
+	ExcludeLo Bounds = 1 << iota
+	ExcludeHi
+	// Inclusive keeps both ends, and Exclusive neither.
+	Inclusive Bounds = 0
+	Exclusive        = ExcludeLo | ExcludeHi
+)
+
+// CountInRange returns the number of keys between lo and hi, including or
+// excluding each end as bounds says, in O(log n) using subtree sizes.
+func (tree *RBTree[K, V]) CountInRange(lo K, hi K, bounds Bounds) int {
+	if tree.compare(lo, hi) > 0 {
+		return 0
+	}
+	// count the keys before hi and before lo, taking the bounds into account
+	upper, found := tree.rank(tree.root, hi)
+	if found && bounds&ExcludeHi == 0 {
+		upper++
 	}
+	lower, found := tree.rank(tree.root, lo)
+	if found && bounds&ExcludeLo != 0 {
+		lower++
+	}
+	if upper < lower {
+		// lo == hi with a bound excluded
+		return 0
+	}
+	return upper - lower
 }
 
-func (tree *RBTree) Select(k int) Key {
+// Select returns the key of rank k, or false if k is out of range.
+func (tree *RBTree[K, V]) Select(k int) (K, bool) {
+	var zero K
 	if k < 0 || k >= tree.Size() {
-		return nil
+		return zero, false
 	}
 	node := selectNode(tree.root, k)
 	if node != nil {
-		return node.key
+		return node.key, true
 	} else {
-		return nil
+		return zero, false
 	}
 }
 
-func selectNode(node *Node, k int) *Node {
+func selectNode[K, V any](node *Node[K, V], k int) *Node[K, V] {
 	if node == nil {
 		return nil
 	}
@@ -352,172 +686,321 @@ func selectNode(node *Node, k int) *Node {
 	}
 }
 
-func (tree *RBTree) DeleteMin() {
+// DeleteMin removes the smallest key from the tree, returning the key and its
+// value, or false if the tree is empty.
+func (tree *RBTree[K, V]) DeleteMin() (K, V, bool) {
+	var removed entry[K, V]
 	if tree.IsEmpty() {
-		return
+		return removed.key, removed.value, false
 	}
+	tree.mods++
 	// if both children of root are black, set root to red
 	if !isRed(tree.root.left) && !isRed(tree.root.right) {
+		tree.root = tree.mutable(tree.root)
 		tree.root.colour = RED
 	}
-	tree.root = deleteMin(tree.root)
+	tree.root = tree.deleteMin(tree.root, &removed)
+	tree.traceKey(EventNodeRemoved, removed.key)
 	if !tree.IsEmpty() {
 		tree.root.colour = BLACK
 	}
+	return removed.key, removed.value, true
 }
 
-func deleteMin(node *Node) *Node {
+// deleteMin reports nothing to the tracer, since join2 hangs the key it
+// removes back in; callers that drop it report it.
+func (tree *RBTree[K, V]) deleteMin(node *Node[K, V], removed *entry[K, V]) *Node[K, V] {
 	if node.left == nil {
+		removed.set(node)
 		return nil
 	}
+	node = tree.mutable(node)
 	if !isRed(node.left) && !isRed(node.left.left) {
-		node = moveRedLeft(node)
+		node = tree.moveRedLeft(node)
 	}
-	node.left = deleteMin(node.left)
-	return balance(node)
+	node.left = tree.deleteMin(node.left, removed)
+	return tree.balance(node)
 }
 
-func balance(node *Node) *Node {
+func (tree *RBTree[K, V]) balance(node *Node[K, V]) *Node[K, V] {
+	node = tree.mutable(node)
 	if isRed(node.right) {
-		node = rotateLeft(node)
+		node = tree.rotateLeft(node)
 	}
 	if isRed(node.left) && isRed(node.left.left) {
-		node = rotateRight(node)
+		node = tree.rotateRight(node)
 	}
 	if isRed(node.left) && isRed(node.right) {
-		flipColours(node)
+		tree.flipColours(node)
 	}
-	node.N = size(node.left) + 1 + size(node.right)
+	resize(node)
 	return node
 }
 
-func moveRedLeft(node *Node) *Node {
+func (tree *RBTree[K, V]) moveRedLeft(node *Node[K, V]) *Node[K, V] {
 	// assuming that node is red and both node.left and node.left.left are black
 	// make node.left or one of its children red
-	flipColours(node)
+	tree.trace(EventMoveRedLeft, node)
+	tree.flipColours(node)
 	if isRed(node.right.left) {
-		node.right = rotateRight(node.right)
-		node = rotateLeft(node)
-		flipColours(node)
+		node.right = tree.rotateRight(node.right)
+		node = tree.rotateLeft(node)
+		tree.flipColours(node)
 	}
 	return node
 }
 
-func (tree *RBTree) DeleteMax() {
+// DeleteMax removes the largest key from the tree, returning the key and its
+// value, or false if the tree is empty.
+func (tree *RBTree[K, V]) DeleteMax() (K, V, bool) {
+	var removed entry[K, V]
 	if tree.IsEmpty() {
-		return
+		return removed.key, removed.value, false
 	}
+	tree.mods++
 	// if both children black, set root red
 	if !isRed(tree.root.left) && !isRed(tree.root.right) {
+		tree.root = tree.mutable(tree.root)
 		tree.root.colour = RED
 	}
-	tree.root = deleteMax(tree.root)
+	tree.root = tree.deleteMax(tree.root, &removed)
 	if !tree.IsEmpty() {
 		tree.root.colour = BLACK
 	}
+	return removed.key, removed.value, true
 }
 
-func deleteMax(node *Node) *Node {
+func (tree *RBTree[K, V]) deleteMax(node *Node[K, V], removed *entry[K, V]) *Node[K, V] {
 	if isRed(node.left) {
-		node = rotateRight(node)
+		node = tree.rotateRight(node)
 	}
 	if node.right == nil {
+		tree.trace(EventNodeRemoved, node)
+		removed.set(node)
 		return nil
 	}
+	node = tree.mutable(node)
 	if !isRed(node.right) && !isRed(node.right.left) {
-		node = moveRedRight(node)
+		node = tree.moveRedRight(node)
 	}
-	node.right = deleteMax(node.right)
-	return balance(node)
+	node.right = tree.deleteMax(node.right, removed)
+	return tree.balance(node)
 }
 
-func moveRedRight(node *Node) *Node {
+func (tree *RBTree[K, V]) moveRedRight(node *Node[K, V]) *Node[K, V] {
 	// assuming node is red and both node.right and node.right.left are black
 	// make node.right or one of its children red
-	flipColours(node)
+	tree.trace(EventMoveRedRight, node)
+	tree.flipColours(node)
 	if isRed(node.left.left) {
-		node = rotateRight(node)
-		flipColours(node)
+		node = tree.rotateRight(node)
+		tree.flipColours(node)
 	}
 	return node
 }
 
-func (tree *RBTree) Height() int {
+// Height returns the number of links on the longest path from the root, 0
+// for an empty tree, in O(1).
+func (tree *RBTree[K, V]) Height() int {
 	if tree.IsEmpty() {
 		return 0
 	}
 	return height(tree.root)
 }
 
-func height(node *Node) int {
+// height returns the height kept in node, -1 for nil.
+func height[K, V any](node *Node[K, V]) int {
 	if node == nil {
 		return -1
 	} else {
-		leftHeight := height(node.left)
-		rightHeight := height(node.right)
-		if leftHeight >= rightHeight {
-			return 1 + leftHeight
-		} else {
-			return 1 + rightHeight
-		}
+		return int(node.height)
 	}
 }
 
-func (tree *RBTree) Keys() []Key {
-	return tree.KeysInRange(tree.Min(), tree.Max())
+// resize recomputes node's size and height from its children.
+func resize[K, V any](node *Node[K, V]) {
+	node.N = 1 + size(node.left) + size(node.right)
+	leftHeight := height(node.left)
+	rightHeight := height(node.right)
+	if leftHeight >= rightHeight {
+		node.height = int32(1 + leftHeight)
+	} else {
+		node.height = int32(1 + rightHeight)
+	}
+}
+
+func (tree *RBTree[K, V]) Keys() []K {
+	lo, _ := tree.Min()
+	hi, _ := tree.Max()
+	return tree.KeysInRange(lo, hi)
 }
 
-func (tree *RBTree) KeysInRange(lo Key, hi Key) []Key {
-	queue := make([]Key, 0, 0)
-	if tree.IsEmpty() || lo.CompareTo(hi) > 0 {
+func (tree *RBTree[K, V]) KeysInRange(lo K, hi K) []K {
+	queue := make([]K, 0, 0)
+	if tree.IsEmpty() || tree.compare(lo, hi) > 0 {
 		return queue
 	}
 
-	keys(tree.root, &queue, lo, hi)
+	tree.keys(tree.root, &queue, lo, hi)
 	return queue
 }
 
-func keys(node *Node, queue *[]Key, lo Key, hi Key) {
+func (tree *RBTree[K, V]) keys(node *Node[K, V], queue *[]K, lo K, hi K) {
 	if node == nil {
 		return
 	}
-	cmplo := lo.CompareTo(node.key)
-	cmphi := hi.CompareTo(node.key)
+	cmplo := tree.compare(lo, node.key)
+	cmphi := tree.compare(hi, node.key)
 	if cmplo < 0 {
-		keys(node.left, queue, lo, hi)
+		tree.keys(node.left, queue, lo, hi)
 	}
 	if cmplo <= 0 && cmphi >= 0 {
 		*queue = append(*queue, node.key)
 	}
 	if cmphi > 0 {
-		keys(node.right, queue, lo, hi)
+		tree.keys(node.right, queue, lo, hi)
+	}
+}
+
+// KeysDesc returns the keys in the tree in descending order.
+func (tree *RBTree[K, V]) KeysDesc() []K {
+	lo, _ := tree.Min()
+	hi, _ := tree.Max()
+	return tree.KeysInRangeDesc(hi, lo)
+}
+
+// KeysInRangeDesc returns the keys in [lo, hi] in descending order. Note hi
+// comes first.
+func (tree *RBTree[K, V]) KeysInRangeDesc(hi K, lo K) []K {
+	queue := make([]K, 0, 0)
+	if tree.IsEmpty() || tree.compare(lo, hi) > 0 {
+		return queue
+	}
+
+	tree.descendRange(tree.root, hi, lo, func(key K, _ V) bool {
+		queue = append(queue, key)
+		return true
+	})
+	return queue
+}
+
+// Values returns the values in the tree, in key order.
+func (tree *RBTree[K, V]) Values() []V {
+	lo, _ := tree.Min()
+	hi, _ := tree.Max()
+	return tree.ValuesInRange(lo, hi)
+}
+
+// ValuesInRange returns the values whose keys are in [lo, hi], in key order.
+func (tree *RBTree[K, V]) ValuesInRange(lo K, hi K) []V {
+	queue := make([]V, 0, 0)
+	if tree.IsEmpty() || tree.compare(lo, hi) > 0 {
+		return queue
+	}
+
+	tree.values(tree.root, &queue, lo, hi)
+	return queue
+}
+
+func (tree *RBTree[K, V]) values(node *Node[K, V], queue *[]V, lo K, hi K) {
+	if node == nil {
+		return
+	}
+	cmplo := tree.compare(lo, node.key)
+	cmphi := tree.compare(hi, node.key)
+	if cmplo < 0 {
+		tree.values(node.left, queue, lo, hi)
+	}
+	if cmplo <= 0 && cmphi >= 0 {
+		*queue = append(*queue, node.value)
+	}
+	if cmphi > 0 {
+		tree.values(node.right, queue, lo, hi)
 	}
 }
 
-func (tree *RBTree) KeysCh(quit <-chan struct{}) <-chan Key {
-	return tree.KeysInRangeCh(quit, tree.Min(), tree.Max())
+// KeysCh sends the keys in order on the returned channel. Like every channel
+// scan it walks a view of the tree taken when it is called, so the tree may be
+// written during the scan without the scan seeing it. Close quit if you stop
+// reading early.
+func (tree *RBTree[K, V]) KeysCh(quit <-chan struct{}) <-chan K {
+	view := tree.view()
+	lo, _ := view.Min()
+	hi, _ := view.Max()
+	return view.keysInRangeCh(quit, lo, hi)
+}
+
+// KeysInRangeCh is the range version of KeysCh.
+func (tree *RBTree[K, V]) KeysInRangeCh(quit <-chan struct{}, lo K, hi K) <-chan K {
+	return tree.view().keysInRangeCh(quit, lo, hi)
 }
 
-func (tree *RBTree) KeysInRangeCh(quit <-chan struct{}, lo Key, hi Key) <-chan Key {
+// keysInRangeCh scans tree, which must not change.
+func (tree *RBTree[K, V]) keysInRangeCh(quit <-chan struct{}, lo K, hi K) <-chan K {
 
-	out := make(chan Key)
+	out := make(chan K)
 
 	go func() {
-		keysCh(quit, out, tree.root, lo, hi)
+		tree.keysCh(quit, out, tree.root, lo, hi)
 		close(out)
 	}()
 
 	return out
 }
 
-func keysCh(quit <-chan struct{}, ch chan Key, node *Node, lo Key, hi Key) {
+// KeysDescCh sends the keys in descending order on the returned channel,
+// stopping after limit keys if limit is positive.
+func (tree *RBTree[K, V]) KeysDescCh(quit <-chan struct{}, limit int) <-chan K {
+	view := tree.view()
+	lo, _ := view.Min()
+	hi, _ := view.Max()
+	return view.keysInRangeDescCh(quit, hi, lo, limit)
+}
+
+// KeysInRangeDescCh is the range version of KeysDescCh. Note hi comes first.
+func (tree *RBTree[K, V]) KeysInRangeDescCh(quit <-chan struct{}, hi K, lo K, limit int) <-chan K {
+	return tree.view().keysInRangeDescCh(quit, hi, lo, limit)
+}
+
+// keysInRangeDescCh scans tree, which must not change.
+func (tree *RBTree[K, V]) keysInRangeDescCh(quit <-chan struct{}, hi K, lo K, limit int) <-chan K {
+
+	out := make(chan K)
+
+	go func() {
+		tree.keysDescCh(quit, out, hi, lo, limit)
+		close(out)
+	}()
+
+	return out
+}
+
+// keysDescCh sends the keys in [lo, hi] on ch from the top down until it has
+// sent limit of them or quit is closed.
+func (tree *RBTree[K, V]) keysDescCh(quit <-chan struct{}, ch chan K, hi K, lo K, limit int) {
+	if tree.compare(lo, hi) > 0 {
+		return
+	}
+	sent := 0
+	tree.descendRange(tree.root, hi, lo, func(key K, _ V) bool {
+		select {
+		case <-quit:
+			return false
+		case ch <- key:
+			sent++
+			return limit <= 0 || sent < limit
+		}
+	})
+}
+
+func (tree *RBTree[K, V]) keysCh(quit <-chan struct{}, ch chan K, node *Node[K, V], lo K, hi K) {
 	if node == nil {
 		return
 	}
-	cmplo := lo.CompareTo(node.key)
-	cmphi := hi.CompareTo(node.key)
+	cmplo := tree.compare(lo, node.key)
+	cmphi := tree.compare(hi, node.key)
 	if cmplo < 0 {
-		keysCh(quit, ch, node.left, lo, hi)
+		tree.keysCh(quit, ch, node.left, lo, hi)
 	}
 	if cmplo <= 0 && cmphi >= 0 {
 		select {
@@ -528,6 +1011,6 @@ func keysCh(quit <-chan struct{}, ch chan Key, node *Node, lo Key, hi Key) {
 		}
 	}
 	if cmphi > 0 {
-		keysCh(quit, ch, node.right, lo, hi)
+		tree.keysCh(quit, ch, node.right, lo, hi)
 	}
 }
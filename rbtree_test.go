@@ -4,6 +4,7 @@ import (
 	"log"
 	"math"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -57,16 +58,16 @@ func TestPutGetDeleteStringKey(t *testing.T) {
 
 	defer logElapsedTime(time.Now(), "TestPutGetDeleteStringKey")
 
-	tree := NewRBTree()
+	tree := NewRBTree[*StringKey]()
 
-	tree.Put(stringKey)
+	tree.Put(stringKey, stringKey)
 
-	key, err := tree.Get(&StringKey{key: stringKey.key}).(*StringKey)
-	if !err {
-		t.Error("tree.Get: invalid type")
+	key, ok := tree.Get(&StringKey{key: stringKey.key})
+	if !ok {
+		t.Error("tree.Get: key not found")
 	}
 	if key != stringKey {
-		t.Errorf("tree.Get: expected: %t, got: %t", stringKey, key)
+		t.Errorf("tree.Get: expected: %v, got: %v", stringKey, key)
 	}
 
 	tree.Delete(key)
@@ -79,36 +80,36 @@ func TestPutGetDeleteStringKeys(t *testing.T) {
 
 	f := func() {
 		defer logElapsedTime(time.Now(), "TestPutGetDeleteStringKeys")
-		tree := NewRBTree()
+		tree := NewOrdered[string, string]()
 
 		for i := 0; i < maxKeys; i++ {
 			s := strconv.Itoa(i)
-			tree.Put(&StringKey{key: s, value: s})
+			tree.Put(s, s)
 		}
 
 		for i := 0; i < maxKeys; i++ {
 			s := strconv.Itoa(i)
-			key, err := tree.Get(&StringKey{key: s}).(*StringKey)
-			if !err {
-				t.Error("invalid type")
+			value, ok := tree.Get(s)
+			if !ok {
+				t.Errorf("tree.Get: missing %s", s)
 			}
-			if key.value != s {
-				t.Error("tree.Get: expected: %s, got: %s", s, key.value)
+			if value != s {
+				t.Errorf("tree.Get: expected: %s, got: %s", s, value)
 			}
 		}
 
 		for i := 0; i < maxKeys; i++ {
 			s := strconv.Itoa(i)
-			tree.Delete(&StringKey{key: s})
-			key := tree.Get(&StringKey{key: s})
-			if key != nil {
-				t.Errorf("found key when shouldn't have: %t", key)
+			tree.Delete(s)
+			value, ok := tree.Get(s)
+			if ok {
+				t.Errorf("found key when shouldn't have: %s", value)
 			}
 
 		}
 
 		if tree.Size() != 0 {
-			t.Error("tree size not zero: %d", tree.Size())
+			t.Errorf("tree size not zero: %d", tree.Size())
 		}
 	}
 
@@ -121,16 +122,16 @@ func TestPutIntKey(t *testing.T) {
 
 	defer logElapsedTime(time.Now(), "TestPutIntKey")
 
-	tree := NewRBTree()
+	tree := NewRBTree[*IntKey]()
 
-	tree.Put(intKey)
+	tree.Put(intKey, intKey)
 
-	key, err := tree.Get(&IntKey{key: intKey.key}).(*IntKey)
-	if !err {
-		t.Error("tree.Get: invalid type")
+	key, ok := tree.Get(&IntKey{key: intKey.key})
+	if !ok {
+		t.Error("tree.Get: key not found")
 	}
 	if key != intKey {
-		t.Errorf("tree.Get: expected: %t, got: %t", intKey, key)
+		t.Errorf("tree.Get: expected: %v, got: %v", intKey, key)
 	}
 
 	tree.Delete(key)
@@ -143,34 +144,34 @@ func TestPutGetDeleteIntKeys(t *testing.T) {
 
 	f := func() {
 		defer logElapsedTime(time.Now(), "TestPutGetDeleteIntKeys")
-		tree := NewRBTree()
+		tree := NewOrdered[int, int]()
 
 		for i := 0; i < maxKeys; i++ {
-			tree.Put(&IntKey{key: i, value: i})
+			tree.Put(i, i)
 		}
 
 		for i := 0; i < maxKeys; i++ {
-			key, err := tree.Get(&IntKey{key: i}).(*IntKey)
-			if !err {
-				t.Error("invalid type")
+			value, ok := tree.Get(i)
+			if !ok {
+				t.Errorf("tree.Get: missing %d", i)
 			}
-			if key.value != i {
-				t.Errorf("tree.Get: expected: %d, got: %d", i, key.value)
+			if value != i {
+				t.Errorf("tree.Get: expected: %d, got: %d", i, value)
 			}
 		}
 
 		for i := 0; i < maxKeys; i++ {
 
-			tree.Delete(&IntKey{key: i})
-			key := tree.Get(&IntKey{key: i})
-			if key != nil {
-				t.Errorf("found key when shouldn't have: %t", key)
+			tree.Delete(i)
+			value, ok := tree.Get(i)
+			if ok {
+				t.Errorf("found key when shouldn't have: %d", value)
 			}
 
 		}
 
 		if tree.Size() != 0 {
-			t.Error("tree size not zero: %d", tree.Size())
+			t.Errorf("tree size not zero: %d", tree.Size())
 		}
 	}
 
@@ -182,13 +183,13 @@ func TestPutGetDeleteIntKeys(t *testing.T) {
 func TestContainsIntKeys(t *testing.T) {
 
 	defer logElapsedTime(time.Now(), "TestContainsIntKeys")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 	for i := 0; i < maxKeys; i++ {
-		if !tree.Contains(&IntKey{key: i}) {
+		if !tree.Contains(i) {
 			t.Errorf("contains: missing %d", i)
 		}
 	}
@@ -197,15 +198,15 @@ func TestContainsIntKeys(t *testing.T) {
 func TestContainsStringKeys(t *testing.T) {
 
 	defer logElapsedTime(time.Now(), "TestContainsStringKeys")
-	tree := NewRBTree()
+	tree := NewOrdered[string, string]()
 
 	for i := 0; i < maxKeys; i++ {
 		s := strconv.Itoa(i)
-		tree.Put(&StringKey{key: s, value: s})
+		tree.Put(s, s)
 	}
 	for i := 0; i < maxKeys; i++ {
 		s := strconv.Itoa(i)
-		if !tree.Contains(&StringKey{key: s}) {
+		if !tree.Contains(s) {
 			t.Errorf("contains: missing %s", s)
 		}
 	}
@@ -213,9 +214,9 @@ func TestContainsStringKeys(t *testing.T) {
 
 func TestSize(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestSize")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 	if tree.Size() != maxKeys {
 		t.Errorf("tree.Size(), expected: %d, got: %d", maxKeys, tree.Size())
@@ -224,9 +225,9 @@ func TestSize(t *testing.T) {
 
 func TestHeight(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestHeight")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 	maxHeight := math.Ilogb(float64(maxKeys))
 	if tree.Height() > maxHeight {
@@ -236,7 +237,7 @@ func TestHeight(t *testing.T) {
 
 func TestIsEmpty(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestIsEmpty")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	if !tree.IsEmpty() {
 		t.Errorf("tree.IsEmpty(), expected: %t, got: %t", true, tree.IsEmpty())
 	}
@@ -244,56 +245,117 @@ func TestIsEmpty(t *testing.T) {
 
 func TestFloorCeiling(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestFloorCeiling")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 
 	mid := maxKeys / 2
 
-	keyFloor, _ := tree.Floor(&IntKey{key: mid}).(*IntKey)
-	keyCeiling, _ := tree.Ceiling(&IntKey{key: mid}).(*IntKey)
-	if !((keyFloor.key <= mid) && (mid <= keyCeiling.key)) {
-		t.Errorf("!(floor:%d <= mid:%d <= ceiling:%d)", keyFloor.key, mid, keyCeiling.key)
+	keyFloor, _ := tree.Floor(mid)
+	keyCeiling, _ := tree.Ceiling(mid)
+	if !((keyFloor <= mid) && (mid <= keyCeiling)) {
+		t.Errorf("!(floor:%d <= mid:%d <= ceiling:%d)", keyFloor, mid, keyCeiling)
 	}
 }
 
 func TestRankSelect(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestRankSelect")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 
 	mid := maxKeys / 2
 
-	key, _ := tree.Floor(&IntKey{key: mid}).(*IntKey)
+	key, _ := tree.Floor(mid)
 
 	rank := tree.Rank(key)
-	if rank != key.key {
-		t.Errorf("tree.Rank: expected: %d, got: %d", key.key, rank)
+	if rank != key {
+		t.Errorf("tree.Rank: expected: %d, got: %d", key, rank)
 	}
 
-	selected, _ := tree.Select(rank).(*IntKey)
+	selected, _ := tree.Select(rank)
 	if selected != key {
-		t.Errorf("tree.Select: expected: %t, got: %t", key, selected)
+		t.Errorf("tree.Select: expected: %d, got: %d", key, selected)
 	}
 
 }
 
+func TestRankFloorCeiling(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestRankFloorCeiling")
+	tree := NewOrdered[int, int]()
+	if _, ok := tree.RankFloor(0); ok {
+		t.Error("tree.RankFloor: found rank in empty tree")
+	}
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(2*i, i)
+	}
+
+	for key := -1; key <= 2*maxKeys; key++ {
+		floor, floorOk := tree.RankFloor(key)
+		ceiling, ceilingOk := tree.RankCeiling(key)
+		if key < 0 {
+			if floorOk || !ceilingOk || ceiling != 0 {
+				t.Errorf("tree.RankFloor/RankCeiling(%d): got: %d %t, %d %t", key, floor, floorOk, ceiling, ceilingOk)
+			}
+		} else if key >= 2*maxKeys-1 {
+			if !floorOk || floor != maxKeys-1 || ceilingOk {
+				t.Errorf("tree.RankFloor/RankCeiling(%d): got: %d %t, %d %t", key, floor, floorOk, ceiling, ceilingOk)
+			}
+		} else if floor != key/2 || ceiling != (key+1)/2 || !floorOk || !ceilingOk {
+			t.Errorf("tree.RankFloor/RankCeiling(%d): expected: %d, %d, got: %d, %d", key, key/2, (key+1)/2, floor, ceiling)
+		}
+	}
+}
+
+func TestCountInRange(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestCountInRange")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(2*i, i)
+	}
+
+	tests := []struct {
+		lo, hi   int
+		bounds   Bounds
+		expected int
+	}{
+		{0, 10, Inclusive, 6},
+		{0, 10, ExcludeLo, 5},
+		{0, 10, ExcludeHi, 5},
+		{0, 10, Exclusive, 4},
+		{1, 9, Inclusive, 4},
+		{1, 9, Exclusive, 4},
+		{4, 4, Inclusive, 1},
+		{4, 4, ExcludeLo, 0},
+		{4, 4, Exclusive, 0},
+		{5, 5, Inclusive, 0},
+		{10, 0, Inclusive, 0},
+		{-100, 2 * maxKeys, Inclusive, maxKeys},
+		{-100, -1, Inclusive, 0},
+	}
+	for _, test := range tests {
+		count := tree.CountInRange(test.lo, test.hi, test.bounds)
+		if count != test.expected {
+			t.Errorf("tree.CountInRange(%d, %d, %d): expected: %d, got: %d", test.lo, test.hi, test.bounds, test.expected, count)
+		}
+	}
+}
+
 func TestMinMax(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestMinMax")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 
-	min := tree.Min().(*IntKey).key
+	min, _ := tree.Min()
 	if min != 0 {
 		t.Errorf("tree.Min: expected: %d, got: %d", 0, min)
 	}
 
-	max := tree.Max().(*IntKey).key
+	max, _ := tree.Max()
 	if max != maxKeys-1 {
 		t.Errorf("tree.Max: expected: %d, got: %d", maxKeys-1, max)
 	}
@@ -301,19 +363,19 @@ func TestMinMax(t *testing.T) {
 
 func TestDeleteMinMax(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestDeleteMinMax")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 
 	tree.DeleteMin()
-	min := tree.Min().(*IntKey).key
+	min, _ := tree.Min()
 	if min != 1 {
 		t.Errorf("tree.Min: expected: %d, got: %d", 1, min)
 	}
 
 	tree.DeleteMax()
-	max := tree.Max().(*IntKey).key
+	max, _ := tree.Max()
 	if max != maxKeys-2 {
 		t.Errorf("tree.Max: expected: %d, got: %d", maxKeys-2, max)
 	}
@@ -321,19 +383,18 @@ func TestDeleteMinMax(t *testing.T) {
 
 func TestKeysSlice(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestKeysSlice")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 
 	keys := tree.Keys()
 	if len(keys) != maxKeys {
 		t.Errorf("tree.Keys, size invalid, expected: %d, got: %d", maxKeys, len(keys))
 	}
-	for i, _key := range keys {
-		key := _key.(*IntKey)
-		if i != key.key {
-			t.Errorf("tree.Keys: invalid item, expected: %d, got: %d", i, key.key)
+	for i, key := range keys {
+		if i != key {
+			t.Errorf("tree.Keys: invalid item, expected: %d, got: %d", i, key)
 		}
 		//log.Println(key.value)
 	}
@@ -341,9 +402,9 @@ func TestKeysSlice(t *testing.T) {
 
 func TestKeysCh(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestKeysCh")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i++ {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 
 	quit := make(chan struct{})
@@ -352,10 +413,9 @@ func TestKeysCh(t *testing.T) {
 
 	var count int = 0
 	var sum int = 0
-	for _key := range keys {
-		key := _key.(*IntKey)
+	for key := range keys {
 		count++
-		sum += key.value
+		sum += key
 		if count == 101 {
 			break
 		}
@@ -373,23 +433,22 @@ func TestKeysCh(t *testing.T) {
 
 func TestKeysInRangeCh(t *testing.T) {
 	defer logElapsedTime(time.Now(), "TestKeysInRangeCh")
-	tree := NewRBTree()
+	tree := NewOrdered[int, int]()
 	for i := 0; i < maxKeys; i += 2 {
-		tree.Put(&IntKey{key: i, value: i})
+		tree.Put(i, i)
 	}
 
 	quit := make(chan struct{})
 
-	keys := tree.KeysInRangeCh(quit, &IntKey{key: 2000}, &IntKey{key: 3000})
+	keys := tree.KeysInRangeCh(quit, 2000, 3000)
 
 	var count int = 0
 	var sum int = 0
-	for _key := range keys {
-		key := _key.(*IntKey)
+	for key := range keys {
 		//log.Println(key)
-		tree.Put(&IntKey{key: key.key + 1, value: key.value * 10})
+		tree.Put(key+1, key*10)
 		count++
-		sum += key.value
+		sum += key
 		if count == 101 {
 			break
 		}
@@ -400,3 +459,311 @@ func TestKeysInRangeCh(t *testing.T) {
 	}
 	//log.Println(sum)
 }
+
+func TestKeysDesc(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestKeysDesc")
+	tree := NewOrdered[int, int]()
+	if keys := tree.KeysDesc(); len(keys) != 0 {
+		t.Errorf("tree.KeysDesc, size invalid, expected: %d, got: %d", 0, len(keys))
+	}
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	keys := tree.KeysDesc()
+	if len(keys) != maxKeys {
+		t.Errorf("tree.KeysDesc, size invalid, expected: %d, got: %d", maxKeys, len(keys))
+	}
+	for i, key := range keys {
+		if key != maxKeys-1-i {
+			t.Errorf("tree.KeysDesc: invalid item, expected: %d, got: %d", maxKeys-1-i, key)
+		}
+	}
+
+	keys = tree.KeysInRangeDesc(3000, 2000)
+	if len(keys) != 1001 || keys[0] != 3000 || keys[1000] != 2000 {
+		t.Errorf("tree.KeysInRangeDesc: expected 3000 down to 2000, got: %d keys", len(keys))
+	}
+	if keys := tree.KeysInRangeDesc(2000, 3000); len(keys) != 0 {
+		t.Errorf("tree.KeysInRangeDesc: empty range returned %d keys", len(keys))
+	}
+}
+
+func TestKeysDescCh(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestKeysDescCh")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i += 2 {
+		tree.Put(i, i)
+	}
+
+	quit := make(chan struct{})
+	expected := maxKeys - 2
+	for key := range tree.KeysDescCh(quit, 0) {
+		if key != expected {
+			t.Errorf("tree.KeysDescCh: expected: %d, got: %d", expected, key)
+		}
+		expected -= 2
+	}
+	if expected != -2 {
+		t.Errorf("tree.KeysDescCh, stopped early at: %d", expected)
+	}
+
+	var count int = 0
+	expected = 3000
+	for key := range tree.KeysInRangeDescCh(quit, 3001, 2000, 10) {
+		if key != expected {
+			t.Errorf("tree.KeysInRangeDescCh: expected: %d, got: %d", expected, key)
+		}
+		expected -= 2
+		count++
+	}
+	if count != 10 {
+		t.Errorf("tree.KeysInRangeDescCh, count wrong, expected: %d, got: %d", 10, count)
+	}
+
+	keys := tree.KeysInRangeDescCh(quit, 3000, 2000, 0)
+	<-keys
+	close(quit)
+	for range keys {
+	}
+}
+
+func TestKeysChSnapshot(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestKeysChSnapshot")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	quit := make(chan struct{})
+	defer close(quit)
+	var expected int = 0
+	for key := range tree.KeysCh(quit) {
+		if key != expected {
+			t.Errorf("tree.KeysCh: expected: %d, got: %d", expected, key)
+		}
+		expected++
+		tree.Delete(key)
+		tree.Put(maxKeys+key, key)
+	}
+	if expected != maxKeys {
+		t.Errorf("tree.KeysCh, count wrong, expected: %d, got: %d", maxKeys, expected)
+	}
+	if err := tree.Check(); err != nil {
+		t.Error(err)
+	}
+	if min, _ := tree.Min(); min != maxKeys {
+		t.Errorf("tree.Min: expected: %d, got: %d", maxKeys, min)
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestNewWithComparator")
+	tree := NewWithComparator[int, string](func(a, b int) int { return b - a })
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, strconv.Itoa(i))
+	}
+
+	keys := tree.Keys()
+	for i, key := range keys {
+		if key != maxKeys-1-i {
+			t.Errorf("tree.Keys: invalid item, expected: %d, got: %d", maxKeys-1-i, key)
+		}
+	}
+
+	value, ok := tree.Get(intKey.key)
+	if !ok || value != strconv.Itoa(intKey.key) {
+		t.Errorf("tree.Get: expected: %d, got: %s", intKey.key, value)
+	}
+}
+
+func TestDeleteReturnsValue(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestDeleteReturnsValue")
+	tree := NewOrdered[int, string]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, strconv.Itoa(i))
+	}
+
+	for i := 0; i < maxKeys; i += 2 {
+		key, value, ok := tree.Delete(i)
+		if !ok || key != i || value != strconv.Itoa(i) {
+			t.Errorf("tree.Delete: expected: %d, got: %d %s", i, key, value)
+		}
+	}
+	if _, _, ok := tree.Delete(0); ok {
+		t.Error("tree.Delete: found key already deleted")
+	}
+
+	if tree.Size() != maxKeys/2 {
+		t.Errorf("tree.Size(), expected: %d, got: %d", maxKeys/2, tree.Size())
+	}
+	for i := 1; i < maxKeys; i += 2 {
+		if rank := tree.Rank(i); rank != i/2 {
+			t.Errorf("tree.Rank: expected: %d, got: %d", i/2, rank)
+		}
+		if key, _ := tree.Select(i / 2); key != i {
+			t.Errorf("tree.Select: expected: %d, got: %d", i, key)
+		}
+	}
+}
+
+func TestPutReturnsReplaced(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestPutReturnsReplaced")
+	// keys compare equal ignoring case, so the replaced key can differ
+	tree := NewWithComparator[string, int](func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	if _, _, replaced := tree.Put("key", 1); replaced {
+		t.Error("tree.Put: replaced in empty tree")
+	}
+	old, oldValue, replaced := tree.Put("KEY", 2)
+	if !replaced || old != "key" || oldValue != 1 {
+		t.Errorf("tree.Put: expected: key 1 true, got: %s %d %t", old, oldValue, replaced)
+	}
+	if key, _ := tree.Min(); key != "KEY" || tree.Size() != 1 {
+		t.Errorf("tree.Put: expected KEY alone, got: %s of %d", key, tree.Size())
+	}
+}
+
+func TestDeleteMinMaxReturn(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestDeleteMinMaxReturn")
+	tree := NewOrdered[int, string]()
+	if _, _, ok := tree.DeleteMin(); ok {
+		t.Error("tree.DeleteMin: found key in empty tree")
+	}
+	if _, _, ok := tree.DeleteMax(); ok {
+		t.Error("tree.DeleteMax: found key in empty tree")
+	}
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, strconv.Itoa(i))
+	}
+	for i := 0; i < maxKeys/2; i++ {
+		key, value, ok := tree.DeleteMin()
+		if !ok || key != i || value != strconv.Itoa(i) {
+			t.Errorf("tree.DeleteMin: expected: %d, got: %d %s", i, key, value)
+		}
+		key, value, ok = tree.DeleteMax()
+		if !ok || key != maxKeys-1-i || value != strconv.Itoa(maxKeys-1-i) {
+			t.Errorf("tree.DeleteMax: expected: %d, got: %d %s", maxKeys-1-i, key, value)
+		}
+	}
+	if !tree.IsEmpty() {
+		t.Errorf("tree.IsEmpty: expected empty, got size %d", tree.Size())
+	}
+}
+
+func TestValues(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestValues")
+	tree := NewOrdered[int, string]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, strconv.Itoa(i))
+	}
+
+	values := tree.Values()
+	if len(values) != maxKeys {
+		t.Errorf("tree.Values, size invalid, expected: %d, got: %d", maxKeys, len(values))
+	}
+	for i, value := range values {
+		if value != strconv.Itoa(i) {
+			t.Errorf("tree.Values: invalid item, expected: %d, got: %s", i, value)
+		}
+	}
+
+	values = tree.ValuesInRange(2000, 2999)
+	if len(values) != 1000 || values[0] != "2000" || values[999] != "2999" {
+		t.Errorf("tree.ValuesInRange: expected 2000..2999, got %d values", len(values))
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestSnapshot")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i += 2 {
+		tree.Put(i, i)
+	}
+
+	snapshot := tree.Snapshot()
+	for i := 1; i < maxKeys; i += 2 {
+		tree.Put(i, i)
+	}
+	var deleted int = 0
+	for i := 0; i < maxKeys/2; i += 2 {
+		tree.Delete(i)
+		deleted++
+	}
+	tree.DeleteMax()
+	snapshot.Put(-2, -2)
+
+	if snapshot.Size() != maxKeys/2+1 {
+		t.Errorf("snapshot.Size(), expected: %d, got: %d", maxKeys/2+1, snapshot.Size())
+	}
+	for i := 0; i < maxKeys; i += 2 {
+		if value, ok := snapshot.Get(i); !ok || value != i {
+			t.Errorf("snapshot.Get: expected: %d, got: %d", i, value)
+		}
+		if rank := snapshot.Rank(i); rank != i/2+1 {
+			t.Errorf("snapshot.Rank: expected: %d, got: %d", i/2+1, rank)
+		}
+		if snapshot.Contains(i + 1) {
+			t.Errorf("snapshot.Contains: found %d added after snapshot", i+1)
+		}
+	}
+	keys := snapshot.KeysInRange(2000, 2010)
+	if len(keys) != 6 || keys[0] != 2000 || keys[5] != 2010 {
+		t.Errorf("snapshot.KeysInRange: expected 2000..2010 even, got: %v", keys)
+	}
+
+	if tree.Contains(-2) {
+		t.Error("tree.Contains: found key put into snapshot")
+	}
+	if tree.Size() != maxKeys-deleted-1 {
+		t.Errorf("tree.Size(), expected: %d, got: %d", maxKeys-deleted-1, tree.Size())
+	}
+	for i := maxKeys / 2; i < maxKeys-1; i++ {
+		if rank := tree.Rank(i); rank != i-deleted {
+			t.Errorf("tree.Rank: expected: %d, got: %d", i-deleted, rank)
+		}
+	}
+}
+
+func TestLowerHigher(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestLowerHigher")
+	tree := NewOrdered[int, int]()
+	if _, _, _, ok := tree.Neighbours(0); ok {
+		t.Error("tree.Neighbours: found key in empty tree")
+	}
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(2*i, i)
+	}
+
+	for key := -1; key <= 2*maxKeys; key++ {
+		expectedLower := (key+1)/2*2 - 2
+		expectedHigher := key/2*2 + 2
+		if key < 0 {
+			expectedHigher = 0
+		}
+		lower, lowerOk := tree.Lower(key)
+		if (lower != expectedLower || !lowerOk) && expectedLower >= 0 {
+			t.Errorf("tree.Lower(%d): expected: %d, got: %d", key, expectedLower, lower)
+		} else if expectedLower < 0 && lowerOk {
+			t.Errorf("tree.Lower(%d): expected none, got: %d", key, lower)
+		}
+		higher, higherOk := tree.Higher(key)
+		if (higher != expectedHigher || !higherOk) && expectedHigher < 2*maxKeys {
+			t.Errorf("tree.Higher(%d): expected: %d, got: %d", key, expectedHigher, higher)
+		} else if expectedHigher >= 2*maxKeys && higherOk {
+			t.Errorf("tree.Higher(%d): expected none, got: %d", key, higher)
+		}
+		nl, nlOk, nh, nhOk := tree.Neighbours(key)
+		if nl != lower || nlOk != lowerOk || nh != higher || nhOk != higherOk {
+			t.Errorf("tree.Neighbours(%d): expected: %d %t, %d %t, got: %d %t, %d %t",
+				key, lower, lowerOk, higher, higherOk, nl, nlOk, nh, nhOk)
+		}
+		if p, _ := tree.Predecessor(key); p != lower {
+			t.Errorf("tree.Predecessor(%d): expected: %d, got: %d", key, lower, p)
+		}
+		if s, _ := tree.Successor(key); s != higher {
+			t.Errorf("tree.Successor(%d): expected: %d, got: %d", key, higher, s)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package rbtree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// stringDepth is the number of levels String renders.
+const stringDepth = 6
+
+// PrettyOptions controls how Pretty renders a tree.
+type PrettyOptions[K any] struct {
+	// FormatKey formats a key for display. If nil, keys are formatted with
+	// fmt.Sprint.
+	FormatKey func(key K) string
+	// MaxDepth is the number of levels to render, counting the root as one.
+	// Deeper subtrees are replaced by a line giving their size. Zero means no
+	// limit.
+	MaxDepth int
+}
+
+// WriteDOT writes the tree to w in Graphviz DOT format. Each node is labelled
+// with its key and subtree size, and red links are drawn as red edges.
+func (tree *RBTree[K, V]) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph rbtree {")
+	fmt.Fprintln(bw, "\tnode [shape=circle];")
+	id := 0
+	var walk func(node *Node[K, V]) int
+	walk = func(node *Node[K, V]) int {
+		id++
+		nodeID := id
+		fmt.Fprintf(bw, "\tn%d [label=\"%s\\nN=%d\"];\n", nodeID, dotEscape(fmt.Sprint(node.key)), node.N)
+		for _, child := range []*Node[K, V]{node.left, node.right} {
+			if child == nil {
+				continue
+			}
+			childID := walk(child)
+			if isRed(child) {
+				fmt.Fprintf(bw, "\tn%d -> n%d [color=red];\n", nodeID, childID)
+			} else {
+				fmt.Fprintf(bw, "\tn%d -> n%d;\n", nodeID, childID)
+			}
+		}
+		return nodeID
+	}
+	if tree.root != nil {
+		walk(tree.root)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// String renders the top levels of the tree with Pretty.
+func (tree *RBTree[K, V]) String() string {
+	return tree.Pretty(PrettyOptions[K]{MaxDepth: stringDepth})
+}
+
+// Pretty renders the tree as indented ASCII, one node per line with its left
+// subtree before its right. Red nodes are marked "red" and every node shows
+// its subtree size. A missing child whose sibling is present is shown as ".",
+// so left and right can always be told apart:
+//
+//	4 (N=5)
+//	+-- 2 (N=3 red)
+//	|   +-- 1 (N=1)
+//	|   +-- 3 (N=1)
+//	+-- 5 (N=1)
+func (tree *RBTree[K, V]) Pretty(opts PrettyOptions[K]) string {
+	if tree.root == nil {
+		return "(empty)\n"
+	}
+	format := opts.FormatKey
+	if format == nil {
+		format = func(key K) string { return fmt.Sprint(key) }
+	}
+	var sb strings.Builder
+	var walk func(node *Node[K, V], prefix string, branch string, indent string, depth int)
+	walk = func(node *Node[K, V], prefix string, branch string, indent string, depth int) {
+		sb.WriteString(prefix + branch)
+		if node == nil {
+			sb.WriteString(".\n")
+			return
+		}
+		if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+			fmt.Fprintf(&sb, "... (N=%d)\n", node.N)
+			return
+		}
+		if isRed(node) {
+			fmt.Fprintf(&sb, "%s (N=%d red)\n", format(node.key), node.N)
+		} else {
+			fmt.Fprintf(&sb, "%s (N=%d)\n", format(node.key), node.N)
+		}
+		if node.left == nil && node.right == nil {
+			return
+		}
+		walk(node.left, prefix+indent, "+-- ", "|   ", depth+1)
+		walk(node.right, prefix+indent, "+-- ", "    ", depth+1)
+	}
+	walk(tree.root, "", "", "", 1)
+	return sb.String()
+}
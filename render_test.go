@@ -0,0 +1,102 @@
+package rbtree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPretty(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestPretty")
+	tree := NewOrdered[int, int]()
+	if tree.String() != "(empty)\n" {
+		t.Errorf("tree.String: expected empty, got: %q", tree.String())
+	}
+	for i := 1; i <= 5; i++ {
+		tree.Put(i, i)
+	}
+
+	expected := "4 (N=5)\n" +
+		"+-- 2 (N=3 red)\n" +
+		"|   +-- 1 (N=1)\n" +
+		"|   +-- 3 (N=1)\n" +
+		"+-- 5 (N=1)\n"
+	if tree.String() != expected {
+		t.Errorf("tree.String: expected:\n%s\ngot:\n%s", expected, tree.String())
+	}
+
+	expected = "k4 (N=5)\n" +
+		"+-- k2 (N=3 red)\n" +
+		"|   +-- ... (N=1)\n" +
+		"|   +-- ... (N=1)\n" +
+		"+-- k5 (N=1)\n"
+	pretty := tree.Pretty(PrettyOptions[int]{FormatKey: func(key int) string { return fmt.Sprintf("k%d", key) }, MaxDepth: 2})
+	if pretty != expected {
+		t.Errorf("tree.Pretty: expected:\n%s\ngot:\n%s", expected, pretty)
+	}
+
+	tree.Delete(3)
+	if !strings.Contains(tree.String(), "|   +-- .\n") {
+		t.Errorf("tree.String: expected missing right child, got:\n%s", tree.String())
+	}
+
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+	// stringDepth levels and a line summarising each subtree below them
+	lines := strings.Count(tree.String(), "\n")
+	if lines >= 1<<(stringDepth+1) {
+		t.Errorf("tree.String: expected fewer than %d lines, got: %d", 1<<(stringDepth+1), lines)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteDOT(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestWriteDOT")
+	tree := NewOrdered[string, int]()
+	for i := 0; i < 100; i++ {
+		tree.Put(fmt.Sprintf("key \"%d\"", i), i)
+	}
+
+	var buf bytes.Buffer
+	if err := tree.WriteDOT(&buf); err != nil {
+		t.Fatalf("tree.WriteDOT: %v", err)
+	}
+	dot := buf.String()
+	if !strings.HasPrefix(dot, "digraph rbtree {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("tree.WriteDOT: not a digraph:\n%s", dot)
+	}
+	if edges := strings.Count(dot, "->"); edges != tree.Size()-1 {
+		t.Errorf("tree.WriteDOT: edges expected: %d, got: %d", tree.Size()-1, edges)
+	}
+	reds := 0
+	var walk func(node *Node[string, int])
+	walk = func(node *Node[string, int]) {
+		if node != nil {
+			if isRed(node) {
+				reds++
+			}
+			walk(node.left)
+			walk(node.right)
+		}
+	}
+	walk(tree.root)
+	if count := strings.Count(dot, "[color=red]"); count != reds {
+		t.Errorf("tree.WriteDOT: red edges expected: %d, got: %d", reds, count)
+	}
+	if !strings.Contains(dot, `label="key \"0\"\nN=1"`) {
+		t.Errorf("tree.WriteDOT: key not escaped:\n%s", dot)
+	}
+
+	if err := tree.WriteDOT(failingWriter{}); err == nil {
+		t.Error("tree.WriteDOT: expected write error")
+	}
+}
@@ -0,0 +1,109 @@
+package rbtree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrScanPanic is wrapped by the error a scan reports when the comparator
+// panicked during the walk.
+var ErrScanPanic = errors.New("rbtree: scan panicked")
+
+// ScanOptions configure KeysInRangeCtx. The zero value sends one key per
+// batch on an unbuffered channel, as KeysInRangeCh does.
+type ScanOptions struct {
+	// BatchSize is the most keys sent in one slice. Only the last batch may
+	// be shorter.
+	BatchSize int
+	// Buffer is the capacity of the channel, in batches.
+	Buffer int
+}
+
+// KeyScan is a scan started by KeysInRangeCtx.
+type KeyScan[K any] struct {
+	// C delivers the keys in ascending order, in batches the receiver may
+	// keep, and is closed when the scan ends.
+	C   <-chan []K
+	err error
+}
+
+// Err returns why the scan ended early: the context's error if it was
+// cancelled or its deadline passed, or an error wrapping ErrScanPanic. It
+// returns nil if every key was sent. Call it only after C is closed.
+func (scan *KeyScan[K]) Err() error {
+	return scan.err
+}
+
+// KeysInRangeCtx sends the keys in [lo, hi] on the channel of the returned
+// scan until they run out or ctx is done. Like KeysCh it walks a view of the
+// tree taken when it is called, but unlike KeysCh the scanning goroutine never
+// outlives ctx, so cancelling it is enough to stop a scan the receiver has
+// given up on.
+func (tree *RBTree[K, V]) KeysInRangeCtx(ctx context.Context, lo K, hi K, opts ScanOptions) *KeyScan[K] {
+	return tree.view().keysInRangeCtx(ctx, lo, hi, opts)
+}
+
+// keysInRangeCtx scans tree, which must not change.
+func (tree *RBTree[K, V]) keysInRangeCtx(ctx context.Context, lo K, hi K, opts ScanOptions) *KeyScan[K] {
+	buffer := opts.Buffer
+	if buffer < 0 {
+		buffer = 0
+	}
+	out := make(chan []K, buffer)
+	scan := &KeyScan[K]{C: out}
+
+	go func() {
+		defer close(out)
+		scan.err = tree.scanKeys(ctx, out, lo, hi, opts.BatchSize)
+	}()
+
+	return scan
+}
+
+// scanKeys walks [lo, hi] in order, sending the keys on out in batches of
+// batchSize, and returns ctx's error if it stopped early.
+func (tree *RBTree[K, V]) scanKeys(ctx context.Context, out chan<- []K, lo K, hi K, batchSize int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrScanPanic, r)
+		}
+	}()
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if tree.compare(lo, hi) > 0 {
+		return nil
+	}
+
+	send := func(batch []K) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- batch:
+			return true
+		}
+	}
+	batch := make([]K, 0, batchSize)
+	complete := tree.ascendRange(tree.root, lo, hi, func(key K, _ V) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		default:
+		}
+		batch = append(batch, key)
+		if len(batch) < batchSize {
+			return true
+		}
+		sent := send(batch)
+		batch = make([]K, 0, batchSize)
+		return sent
+	})
+	if !complete || (len(batch) > 0 && !send(batch)) {
+		return ctx.Err()
+	}
+	return nil
+}
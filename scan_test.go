@@ -0,0 +1,112 @@
+package rbtree
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeysInRangeCtx(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestKeysInRangeCtx")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	scan := tree.KeysInRangeCtx(context.Background(), 100, 1099, ScanOptions{BatchSize: 64, Buffer: 2})
+	var expected int = 100
+	var batches int = 0
+	for batch := range scan.C {
+		batches++
+		if len(batch) > 64 {
+			t.Errorf("tree.KeysInRangeCtx: batch too long: %d", len(batch))
+		}
+		for _, key := range batch {
+			if key != expected {
+				t.Errorf("tree.KeysInRangeCtx: expected: %d, got: %d", expected, key)
+			}
+			expected++
+		}
+	}
+	if expected != 1100 {
+		t.Errorf("tree.KeysInRangeCtx, stopped early at: %d", expected)
+	}
+	if batches != 16 {
+		t.Errorf("tree.KeysInRangeCtx, batches wrong, expected: %d, got: %d", 16, batches)
+	}
+	if err := scan.Err(); err != nil {
+		t.Errorf("tree.KeysInRangeCtx: unexpected error: %v", err)
+	}
+
+	scan = tree.KeysInRangeCtx(context.Background(), 5, 7, ScanOptions{})
+	expected = 5
+	for batch := range scan.C {
+		if len(batch) != 1 || batch[0] != expected {
+			t.Errorf("tree.KeysInRangeCtx: expected: [%d], got: %v", expected, batch)
+		}
+		expected++
+	}
+
+	scan = tree.KeysInRangeCtx(context.Background(), 7, 5, ScanOptions{})
+	for batch := range scan.C {
+		t.Errorf("tree.KeysInRangeCtx: empty range sent %v", batch)
+	}
+}
+
+func TestKeysInRangeCtxCancel(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestKeysInRangeCtxCancel")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scan := tree.KeysInRangeCtx(ctx, 0, maxKeys, ScanOptions{BatchSize: 10})
+	<-scan.C
+	cancel()
+	closed := make(chan struct{})
+	go func() {
+		for range scan.C {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tree.KeysInRangeCtx: scan still running after cancel")
+	}
+	if err := scan.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("tree.KeysInRangeCtx: expected: %v, got: %v", context.Canceled, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	scan = tree.KeysInRangeCtx(ctx, 0, maxKeys, ScanOptions{})
+	<-ctx.Done()
+	for range scan.C {
+	}
+	if err := scan.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("tree.KeysInRangeCtx: expected: %v, got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestKeysInRangeCtxPanic(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestKeysInRangeCtxPanic")
+	tree := NewWithComparator[int, int](func(a, b int) int {
+		if a == 13 || b == 13 {
+			panic("unlucky")
+		}
+		return a - b
+	})
+	for i := 0; i < 10; i++ {
+		tree.Put(i, i)
+	}
+
+	scan := tree.KeysInRangeCtx(context.Background(), 0, 13, ScanOptions{})
+	for range scan.C {
+	}
+	if err := scan.Err(); !errors.Is(err, ErrScanPanic) {
+		t.Errorf("tree.KeysInRangeCtx: expected: %v, got: %v", ErrScanPanic, err)
+	}
+}
@@ -0,0 +1,220 @@
+package rbtree
+
+import (
+	"sync"
+)
+
+// The set operations use the split and join primitives from join.go: split one
+// tree around the root key of the other, recurse on the two halves and join
+// the results. For trees of sizes m <= n that does O(m log(n/m + 1)) work,
+// and the two recursive calls are independent, so large inputs run them in
+// parallel.
+//
+// Results share unchanged nodes with their inputs, so like Split and Join
+// these never modify the input trees.
+
+// MergeFunc decides the value kept for a key present in both trees of a Union
+// or Intersection, given the values from the first and second tree.
+// Set operations on large trees call it from several goroutines at once.
+type MergeFunc[K, V any] func(key K, a, b V) V
+
+// parallelThreshold is the combined size below which set operations stop
+// spawning goroutines.
+const parallelThreshold = 1 << 12
+
+// Union returns a tree holding the keys in either a or b. For keys in both,
+// the value is merge(key, aValue, bValue), or a's value if merge is nil. The
+// result is ordered by a's comparator, which b must agree with.
+func Union[K, V any](a, b *RBTree[K, V], merge MergeFunc[K, V]) *RBTree[K, V] {
+	work := setWork(a, b)
+	root, _ := work.union(a.root, blackHeight(a.root), b.root, blackHeight(b.root), merge)
+	work.setRoot(root)
+	return work
+}
+
+// Intersection returns a tree holding the keys in both a and b, with values
+// chosen as for Union.
+func Intersection[K, V any](a, b *RBTree[K, V], merge MergeFunc[K, V]) *RBTree[K, V] {
+	work := setWork(a, b)
+	root, _ := work.intersection(a.root, blackHeight(a.root), b.root, blackHeight(b.root), merge)
+	work.setRoot(root)
+	return work
+}
+
+// Difference returns a tree holding the keys in a that are not in b.
+func Difference[K, V any](a, b *RBTree[K, V]) *RBTree[K, V] {
+	work := setWork(a, b)
+	root, _ := work.difference(a.root, blackHeight(a.root), b.root)
+	work.setRoot(root)
+	return work
+}
+
+// SymmetricDifference returns a tree holding the keys in exactly one of a and
+// b.
+func SymmetricDifference[K, V any](a, b *RBTree[K, V]) *RBTree[K, V] {
+	work := setWork(a, b)
+	root, _ := work.symmetricDifference(a.root, blackHeight(a.root), b.root, blackHeight(b.root))
+	work.setRoot(root)
+	return work
+}
+
+// UnionWith adds the keys of other to the tree, merging values as Union does
+// with the tree as a.
+func (tree *RBTree[K, V]) UnionWith(other *RBTree[K, V], merge MergeFunc[K, V]) {
+	tree.replace(Union(tree, other, merge))
+}
+
+// IntersectWith removes the keys not in other from the tree, merging values as
+// Intersection does with the tree as a.
+func (tree *RBTree[K, V]) IntersectWith(other *RBTree[K, V], merge MergeFunc[K, V]) {
+	tree.replace(Intersection(tree, other, merge))
+}
+
+// DifferenceWith removes the keys in other from the tree.
+func (tree *RBTree[K, V]) DifferenceWith(other *RBTree[K, V]) {
+	tree.replace(Difference(tree, other))
+}
+
+// SymmetricDifferenceWith removes the keys in other from the tree and adds
+// those of other's keys it did not have.
+func (tree *RBTree[K, V]) SymmetricDifferenceWith(other *RBTree[K, V]) {
+	tree.replace(SymmetricDifference(tree, other))
+}
+
+func (tree *RBTree[K, V]) replace(result *RBTree[K, V]) {
+	tree.gen.Store(result.gen.Load())
+	tree.setRoot(result.root)
+}
+
+// setWork freezes a and b, since the result will share their nodes, and
+// returns an empty tree in a new generation to build the result in.
+func setWork[K, V any](a, b *RBTree[K, V]) *RBTree[K, V] {
+	a.freeze()
+	b.freeze()
+	work := a.clone()
+	work.root = nil
+	return work
+}
+
+// both runs left and right, in parallel if n is large enough.
+func both(n int, left func(), right func()) {
+	if n < parallelThreshold {
+		left()
+		right()
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		left()
+	}()
+	right()
+	wg.Wait()
+}
+
+// The helpers below take and return subtrees with their black heights, as
+// join3 needs them.
+
+func (tree *RBTree[K, V]) union(a *Node[K, V], abh int, b *Node[K, V], bbh int, merge MergeFunc[K, V]) (*Node[K, V], int) {
+	if a == nil {
+		return b, bbh
+	}
+	if b == nil {
+		return a, abh
+	}
+	childBh := childBlackHeight(a, abh)
+	bl, blbh, found, br, brbh := tree.split(b, bbh, a.key)
+	var l, r *Node[K, V]
+	var lbh, rbh int
+	both(a.N+size(b),
+		func() { l, lbh = tree.union(a.left, childBh, bl, blbh, merge) },
+		func() { r, rbh = tree.union(a.right, childBh, br, brbh, merge) })
+	mid := a
+	if found != nil && merge != nil {
+		mid = tree.mutable(a)
+		mid.value = merge(a.key, a.value, found.value)
+	}
+	return tree.join3(l, lbh, mid, r, rbh)
+}
+
+func (tree *RBTree[K, V]) intersection(a *Node[K, V], abh int, b *Node[K, V], bbh int, merge MergeFunc[K, V]) (*Node[K, V], int) {
+	if a == nil || b == nil {
+		return nil, 0
+	}
+	childBh := childBlackHeight(a, abh)
+	bl, blbh, found, br, brbh := tree.split(b, bbh, a.key)
+	var l, r *Node[K, V]
+	var lbh, rbh int
+	both(a.N+size(b),
+		func() { l, lbh = tree.intersection(a.left, childBh, bl, blbh, merge) },
+		func() { r, rbh = tree.intersection(a.right, childBh, br, brbh, merge) })
+	if found == nil {
+		return tree.join2(l, lbh, r, rbh)
+	}
+	mid := a
+	if merge != nil {
+		mid = tree.mutable(a)
+		mid.value = merge(a.key, a.value, found.value)
+	}
+	return tree.join3(l, lbh, mid, r, rbh)
+}
+
+func (tree *RBTree[K, V]) difference(a *Node[K, V], abh int, b *Node[K, V]) (*Node[K, V], int) {
+	if a == nil {
+		return nil, 0
+	}
+	if b == nil {
+		return a, abh
+	}
+	al, albh, _, ar, arbh := tree.split(a, abh, b.key)
+	var l, r *Node[K, V]
+	var lbh, rbh int
+	both(a.N+b.N,
+		func() { l, lbh = tree.difference(al, albh, b.left) },
+		func() { r, rbh = tree.difference(ar, arbh, b.right) })
+	return tree.join2(l, lbh, r, rbh)
+}
+
+func (tree *RBTree[K, V]) symmetricDifference(a *Node[K, V], abh int, b *Node[K, V], bbh int) (*Node[K, V], int) {
+	if a == nil {
+		return b, bbh
+	}
+	if b == nil {
+		return a, abh
+	}
+	childBh := childBlackHeight(a, abh)
+	bl, blbh, found, br, brbh := tree.split(b, bbh, a.key)
+	var l, r *Node[K, V]
+	var lbh, rbh int
+	both(a.N+size(b),
+		func() { l, lbh = tree.symmetricDifference(a.left, childBh, bl, blbh) },
+		func() { r, rbh = tree.symmetricDifference(a.right, childBh, br, brbh) })
+	if found != nil {
+		return tree.join2(l, lbh, r, rbh)
+	}
+	return tree.join3(l, lbh, a, r, rbh)
+}
+
+// join2 joins two subtrees without a key between them, by pulling the
+// smallest key out of r to join around.
+func (tree *RBTree[K, V]) join2(l *Node[K, V], lbh int, r *Node[K, V], rbh int) (*Node[K, V], int) {
+	if r == nil {
+		return l, lbh
+	}
+	if l == nil {
+		return r, rbh
+	}
+	// delete from r as DeleteMin does from the root
+	if !isRed(r.left) && !isRed(r.right) {
+		r = tree.mutable(r)
+		r.colour = RED
+	}
+	var first entry[K, V]
+	r = tree.deleteMin(r, &first)
+	if r != nil {
+		r = tree.mutable(r)
+		r.colour = BLACK
+	}
+	return tree.join3(l, lbh, first.node, r, blackHeight(r))
+}
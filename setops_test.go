@@ -0,0 +1,145 @@
+package rbtree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func randomSet(r *rand.Rand, n int, universe int) (*RBTree[int, int], map[int]int) {
+	tree := NewOrdered[int, int]()
+	model := make(map[int]int)
+	for i := 0; i < n; i++ {
+		key := r.Intn(universe)
+		tree.Put(key, key*10+i%10)
+		model[key] = key*10 + i%10
+	}
+	return tree, model
+}
+
+func assertSameAs(t *testing.T, name string, tree *RBTree[int, int], model map[int]int) {
+	t.Helper()
+	assertValid(t, name, tree)
+	if tree.Size() != len(model) {
+		t.Errorf("%s: size wrong, expected: %d, got: %d", name, len(model), tree.Size())
+	}
+	for key, value := range tree.All() {
+		if expected, ok := model[key]; !ok || expected != value {
+			t.Errorf("%s: expected %d=%d, got: %d", name, key, expected, value)
+		}
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestSetOperations")
+	r := rand.New(rand.NewSource(1))
+	sum := func(key int, a, b int) int { return a + b }
+
+	// the largest sizes go over parallelThreshold
+	for _, sizes := range [][2]int{{0, 0}, {0, 50}, {50, 0}, {1, 1}, {20, 300}, {300, 20}, {1000, 1000}, {maxKeys, maxKeys / 2}} {
+		a, am := randomSet(r, sizes[0], 2*(sizes[0]+sizes[1])+1)
+		b, bm := randomSet(r, sizes[1], 2*(sizes[0]+sizes[1])+1)
+
+		union := make(map[int]int)
+		intersection := make(map[int]int)
+		difference := make(map[int]int)
+		symmetric := make(map[int]int)
+		for key, value := range am {
+			union[key] = value
+			if other, ok := bm[key]; ok {
+				union[key] = value + other
+				intersection[key] = value + other
+			} else {
+				difference[key] = value
+				symmetric[key] = value
+			}
+		}
+		for key, value := range bm {
+			if _, ok := am[key]; !ok {
+				union[key] = value
+				symmetric[key] = value
+			}
+		}
+
+		assertSameAs(t, "Union", Union(a, b, sum), union)
+		assertSameAs(t, "Intersection", Intersection(a, b, sum), intersection)
+		assertSameAs(t, "Difference", Difference(a, b), difference)
+		assertSameAs(t, "SymmetricDifference", SymmetricDifference(a, b), symmetric)
+
+		// the inputs are unchanged
+		assertSameAs(t, "a", a, am)
+		assertSameAs(t, "b", b, bm)
+
+		in := a.Snapshot()
+		in.UnionWith(b, sum)
+		assertSameAs(t, "UnionWith", in, union)
+		in = a.Snapshot()
+		in.IntersectWith(b, sum)
+		assertSameAs(t, "IntersectWith", in, intersection)
+		in = a.Snapshot()
+		in.DifferenceWith(b)
+		assertSameAs(t, "DifferenceWith", in, difference)
+		// a-b and b are disjoint, so this adds all of b back
+		in.SymmetricDifferenceWith(b)
+		for key, value := range bm {
+			difference[key] = value
+		}
+		assertSameAs(t, "SymmetricDifferenceWith", in, difference)
+	}
+}
+
+func TestUnionKeepsFirstValue(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestUnionKeepsFirstValue")
+	a := NewOrdered[string, int]()
+	a.Put("x", 1)
+	b := NewOrdered[string, int]()
+	b.Put("x", 2)
+	b.Put("y", 3)
+
+	union := Union(a, b, nil)
+	if value, _ := union.Get("x"); value != 1 {
+		t.Errorf("Union: expected: %d, got: %d", 1, value)
+	}
+	if value, _ := union.Get("y"); value != 3 {
+		t.Errorf("Union: expected: %d, got: %d", 3, value)
+	}
+}
+
+func TestSetOperationsSmall(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestSetOperationsSmall")
+	tree := func(keys ...int) *RBTree[int, int] {
+		tree := NewOrdered[int, int]()
+		for _, key := range keys {
+			tree.Put(key, key)
+		}
+		return tree
+	}
+	model := func(keys ...int) map[int]int {
+		model := make(map[int]int)
+		for _, key := range keys {
+			model[key] = key
+		}
+		return model
+	}
+
+	// splits of small trees leave red roots that must not reach the result
+	a, b := tree(1, 2), tree(2)
+	assertSameAs(t, "Difference", Difference(a, b), model(1))
+	assertSameAs(t, "SymmetricDifference", SymmetricDifference(a, b), model(1))
+	assertSameAs(t, "SymmetricDifference", SymmetricDifference(b, a), model(1))
+	assertSameAs(t, "Union", Union(a, tree(), nil), model(1, 2))
+	assertSameAs(t, "Intersection", Intersection(tree(1, 2, 3), tree(2, 3), nil), model(2, 3))
+
+	in := tree(1, 2)
+	in.DifferenceWith(tree(2))
+	assertSameAs(t, "DifferenceWith", in, model(1))
+	in = tree(1, 2, 3)
+	in.SymmetricDifferenceWith(tree(3, 4))
+	assertSameAs(t, "SymmetricDifferenceWith", in, model(1, 2, 4))
+	in = tree(1, 2)
+	in.UnionWith(tree(), nil)
+	assertSameAs(t, "UnionWith", in, model(1, 2))
+	in = tree(1, 2, 3)
+	in.IntersectWith(tree(1, 2), nil)
+	assertSameAs(t, "IntersectWith", in, model(1, 2))
+}
@@ -0,0 +1,148 @@
+package rbtree
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// Stats describes the work a tree has done and its current shape.
+type Stats struct {
+	// Comparisons, Rotations and ColourFlips count the comparator calls,
+	// rotations and colour flips since the tree was made or ResetStats was
+	// last called. Comparisons stays zero while CountComparisons is off.
+	Comparisons uint64
+	Rotations   uint64
+	ColourFlips uint64
+	// Size is the number of keys, and Height the number of links on the
+	// longest path from the root, 0 for an empty tree, as Height returns.
+	Size   int
+	Height int
+	// BlackHeight is the number of black nodes on every path from the root
+	// to a nil link.
+	BlackHeight int
+	RedNodes    int
+	// Depths[d] is the number of nodes d links below the root.
+	Depths []int
+}
+
+// counters are shared by a tree and the trees Split, Join and the set
+// operations derive from it, which may update them from several goroutines.
+type counters struct {
+	comparisons shardedCounter
+	rotations   atomic.Uint64
+	flips       atomic.Uint64
+}
+
+// counterShards is the number of cache lines a shardedCounter spreads its
+// adds over.
+const counterShards = 16
+
+// shardedCounter is a counter for the hot path of reads. Each add goes to a
+// randomly chosen shard on its own cache line, so readers on different cores
+// rarely touch the same line; Load sums the shards.
+type shardedCounter struct {
+	shards [counterShards]struct {
+		n atomic.Uint64
+		_ [56]byte
+	}
+}
+
+func (c *shardedCounter) Add(n uint64) {
+	c.shards[rand.Uint32()%counterShards].n.Add(n)
+}
+
+func (c *shardedCounter) Load() uint64 {
+	var total uint64
+	for i := range c.shards {
+		total += c.shards[i].n.Load()
+	}
+	return total
+}
+
+func (c *shardedCounter) Reset() {
+	for i := range c.shards {
+		c.shards[i].n.Store(0)
+	}
+}
+
+// instrument points compare at the tree's comparator, wrapped to count calls
+// if counting is on and to report them if there is a tracer. Call it whenever
+// the counters, counting or the tracer change.
+func (tree *RBTree[K, V]) instrument() {
+	compare, counters, tracer := tree.comparator, tree.counters, tree.tracer
+	if !tree.counting && tracer == nil {
+		tree.compare = compare
+	} else if tracer == nil {
+		tree.compare = func(a, b K) int {
+			counters.comparisons.Add(1)
+			return compare(a, b)
+		}
+	} else if !tree.counting {
+		tree.compare = func(a, b K) int {
+			tracer.Event(Event[K]{Kind: EventCompare, Key: a, Other: b})
+			return compare(a, b)
+		}
+	} else {
+		tree.compare = func(a, b K) int {
+			counters.comparisons.Add(1)
+			tracer.Event(Event[K]{Kind: EventCompare, Key: a, Other: b})
+			return compare(a, b)
+		}
+	}
+}
+
+// CountComparisons turns counting of comparator calls for Stats on or off.
+// It is on by default. The count is sharded across cache lines, so readers on
+// many cores don't contend for it; turning it off saves the add on every
+// comparison. Rotations and colour flips happen only in writes and are always
+// counted. Trees made from this one inherit the setting.
+func (tree *RBTree[K, V]) CountComparisons(on bool) {
+	tree.counting = on
+	tree.instrument()
+}
+
+// ownCounters gives the tree new counters, no longer shared with the tree it
+// was cloned from.
+func (tree *RBTree[K, V]) ownCounters() {
+	tree.counters = &counters{}
+	tree.instrument()
+}
+
+// Stats returns the tree's counters and shape. The counters, Size, Height and
+// BlackHeight are read in O(log n), but RedNodes and Depths take a walk of the
+// whole tree, O(n). Trees made from this one by Split, Join and the set
+// operations add to its counters, while Snapshot starts new ones.
+func (tree *RBTree[K, V]) Stats() Stats {
+	stats := Stats{
+		Comparisons: tree.counters.comparisons.Load(),
+		Rotations:   tree.counters.rotations.Load(),
+		ColourFlips: tree.counters.flips.Load(),
+		Size:        tree.Size(),
+		Height:      tree.Height(),
+		BlackHeight: blackHeight(tree.root),
+	}
+	var walk func(node *Node[K, V], depth int)
+	walk = func(node *Node[K, V], depth int) {
+		if node == nil {
+			return
+		}
+		if depth == len(stats.Depths) {
+			stats.Depths = append(stats.Depths, 0)
+		}
+		stats.Depths[depth]++
+		if isRed(node) {
+			stats.RedNodes++
+		}
+		walk(node.left, depth+1)
+		walk(node.right, depth+1)
+	}
+	walk(tree.root, 0)
+	return stats
+}
+
+// ResetStats sets the tree's counters to zero.
+func (tree *RBTree[K, V]) ResetStats() {
+	tree.counters.comparisons.Reset()
+	tree.counters.rotations.Store(0)
+	tree.counters.flips.Store(0)
+}
@@ -0,0 +1,99 @@
+package rbtree
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestStats")
+	tree := NewOrdered[int, int]()
+	tree.CountComparisons(false)
+	tree.Put(0, 0)
+	tree.Get(0)
+	if tree.Stats().Comparisons != 0 {
+		t.Errorf("tree.Stats: counted comparisons while counting was off: %d", tree.Stats().Comparisons)
+	}
+	tree = NewOrdered[int, int]()
+	stats := tree.Stats()
+	if stats.Size != 0 || stats.Height != 0 || stats.BlackHeight != 0 || len(stats.Depths) != 0 {
+		t.Errorf("tree.Stats: empty tree, got: %+v", stats)
+	}
+
+	tree.Put(1, 1)
+	tree.Put(2, 2)
+	tree.Put(3, 3)
+	stats = tree.Stats()
+	expected := Stats{Comparisons: 2, Rotations: 1, ColourFlips: 1, Size: 3, Height: 1, BlackHeight: 2, Depths: []int{1, 2}}
+	if stats.Comparisons != expected.Comparisons || stats.Rotations != expected.Rotations ||
+		stats.ColourFlips != expected.ColourFlips || stats.Size != expected.Size || stats.Height != expected.Height ||
+		stats.BlackHeight != expected.BlackHeight || stats.RedNodes != expected.RedNodes ||
+		!slices.Equal(stats.Depths, expected.Depths) {
+		t.Errorf("tree.Stats: expected: %+v, got: %+v", expected, stats)
+	}
+
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+	stats = tree.Stats()
+	if stats.Height != tree.Height() || len(stats.Depths) != stats.Height+1 {
+		t.Errorf("tree.Stats: height expected: %d, got: %d with %d depths", tree.Height(), stats.Height, len(stats.Depths))
+	}
+	total := 0
+	for _, n := range stats.Depths {
+		total += n
+	}
+	if total != maxKeys || stats.Size != maxKeys {
+		t.Errorf("tree.Stats: depths add up to %d, size %d, expected: %d", total, stats.Size, maxKeys)
+	}
+	if stats.BlackHeight != blackHeight(tree.root) || stats.RedNodes == 0 {
+		t.Errorf("tree.Stats: black height expected: %d, got: %d, %d red nodes", blackHeight(tree.root), stats.BlackHeight, stats.RedNodes)
+	}
+
+	tree.ResetStats()
+	stats = tree.Stats()
+	if stats.Comparisons != 0 || stats.Rotations != 0 || stats.ColourFlips != 0 {
+		t.Errorf("tree.ResetStats: expected zero counters, got: %+v", stats)
+	}
+	tree.Get(maxKeys / 2)
+	if tree.Stats().Comparisons == 0 {
+		t.Error("tree.Stats: Get did not count comparisons")
+	}
+
+	// a snapshot has its own counters
+	before := tree.Stats().Comparisons
+	snapshot := tree.Snapshot()
+	snapshot.Put(-1, -1)
+	if snapshot.Stats().Comparisons == 0 || tree.Stats().Comparisons != before {
+		t.Errorf("tree.Snapshot: counters shared, tree: %+v, snapshot: %+v", tree.Stats(), snapshot.Stats())
+	}
+}
+
+func TestAtomicStats(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestAtomicStats")
+	a := NewAtomic(NewOrdered[int, int]())
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				a.Get(i)
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		a.Put(i, i)
+	}
+	wg.Wait()
+	stats := a.Stats()
+	if stats.Size != 1000 || stats.Comparisons == 0 || stats.Rotations == 0 {
+		t.Errorf("AtomicRBTree.Stats: got: %+v", stats)
+	}
+	a.ResetStats()
+	if a.Stats().Comparisons != 0 {
+		t.Errorf("AtomicRBTree.ResetStats: got: %+v", a.Stats())
+	}
+}
@@ -0,0 +1,104 @@
+package rbtree
+
+import (
+	"fmt"
+)
+
+// EventKind identifies a step reported to a Tracer.
+type EventKind int
+
+const (
+	// EventCompare is a call to the tree's comparator.
+	EventCompare EventKind = iota
+	// EventRotateLeft and EventRotateRight are rotations about a node.
+	EventRotateLeft
+	EventRotateRight
+	// EventFlipColours is a colour flip of a node and its two children.
+	EventFlipColours
+	// EventMoveRedLeft and EventMoveRedRight push a red link down the path
+	// of a delete.
+	EventMoveRedLeft
+	EventMoveRedRight
+	// EventNodeCreated and EventNodeRemoved are a node added to or taken out
+	// of the tree.
+	EventNodeCreated
+	EventNodeRemoved
+)
+
+var eventNames = [...]string{
+	EventCompare:      "Compare",
+	EventRotateLeft:   "RotateLeft",
+	EventRotateRight:  "RotateRight",
+	EventFlipColours:  "FlipColours",
+	EventMoveRedLeft:  "MoveRedLeft",
+	EventMoveRedRight: "MoveRedRight",
+	EventNodeCreated:  "NodeCreated",
+	EventNodeRemoved:  "NodeRemoved",
+}
+
+func (kind EventKind) String() string {
+	if kind >= 0 && int(kind) < len(eventNames) {
+		return eventNames[kind]
+	}
+	return fmt.Sprintf("EventKind(%d)", int(kind))
+}
+
+// Event is one step of a tree operation. Key is the key of the node the step
+// applied to. For EventCompare, Key and Other are the comparator's arguments
+// in order.
+//
+// Deleting a key whose node has two children moves its successor up and
+// removes the successor's node, so the EventNodeRemoved that follows carries
+// the successor's key.
+//
+// Split, Join and the set operations rebuild trees out of existing nodes and
+// report no EventNodeCreated or EventNodeRemoved, except for the key Join3
+// adds. DeleteRange, DeleteBefore and DeleteAfter report each node they drop.
+type Event[K any] struct {
+	Kind  EventKind
+	Key   K
+	Other K
+}
+
+// Tracer receives the steps of tree operations as they happen, in order.
+type Tracer[K any] interface {
+	Event(e Event[K])
+}
+
+// TracerFunc adapts a function to a Tracer.
+type TracerFunc[K any] func(e Event[K])
+
+func (f TracerFunc[K]) Event(e Event[K]) {
+	f(e)
+}
+
+// SetTracer sends the steps of every later operation on the tree to tracer,
+// or stops tracing if tracer is nil. Trees returned by Snapshot, Split, Join
+// and the set operations inherit the tracer, and set operations on large
+// trees call it from several goroutines at once.
+func (tree *RBTree[K, V]) SetTracer(tracer Tracer[K]) {
+	tree.tracer = tracer
+	tree.instrument()
+}
+
+// trace reports a step on node to the tracer, if there is one.
+func (tree *RBTree[K, V]) trace(kind EventKind, node *Node[K, V]) {
+	tree.traceKey(kind, node.key)
+}
+
+// traceKey reports a step on the node that held key.
+func (tree *RBTree[K, V]) traceKey(kind EventKind, key K) {
+	if tree.tracer != nil {
+		tree.tracer.Event(Event[K]{Kind: kind, Key: key})
+	}
+}
+
+// traceRemoved reports every node of a subtree the tree has let go of.
+func (tree *RBTree[K, V]) traceRemoved(node *Node[K, V]) {
+	if tree.tracer == nil || node == nil {
+		return
+	}
+	tree.traceRemoved(node.left)
+	tree.trace(EventNodeRemoved, node)
+	tree.traceRemoved(node.right)
+}
@@ -0,0 +1,127 @@
+package rbtree
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTracerEvents(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestTracerEvents")
+	tree := NewOrdered[int, int]()
+	var events []Event[int]
+	tree.SetTracer(TracerFunc[int](func(e Event[int]) {
+		events = append(events, e)
+	}))
+	tree.Put(1, 1)
+	tree.Put(2, 2)
+	tree.Put(3, 3)
+
+	expected := []Event[int]{
+		{Kind: EventNodeCreated, Key: 1},
+		{Kind: EventCompare, Key: 2, Other: 1},
+		{Kind: EventNodeCreated, Key: 2},
+		{Kind: EventRotateLeft, Key: 1},
+		{Kind: EventCompare, Key: 3, Other: 2},
+		{Kind: EventNodeCreated, Key: 3},
+		{Kind: EventFlipColours, Key: 2},
+	}
+	if !slices.Equal(events, expected) {
+		t.Errorf("tree.SetTracer: expected: %v, got: %v", expected, events)
+	}
+
+	tree.SetTracer(nil)
+	events = nil
+	tree.Put(4, 4)
+	tree.Delete(1)
+	if len(events) != 0 {
+		t.Errorf("tree.SetTracer(nil): expected no events, got: %v", events)
+	}
+}
+
+func TestTracerCounts(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestTracerCounts")
+	tree := NewOrdered[int, int]()
+	counts := make(map[EventKind]int)
+	tree.SetTracer(TracerFunc[int](func(e Event[int]) {
+		counts[e.Kind]++
+	}))
+	for i := 0; i < maxKeys; i++ {
+		tree.Put(i, i)
+	}
+	if counts[EventNodeCreated] != maxKeys {
+		t.Errorf("NodeCreated: expected: %d, got: %d", maxKeys, counts[EventNodeCreated])
+	}
+	if counts[EventRotateLeft] == 0 || counts[EventFlipColours] == 0 || counts[EventCompare] == 0 {
+		t.Errorf("Put: expected rotations, flips and compares, got: %v", counts)
+	}
+
+	for i := 0; i < maxKeys; i += 3 {
+		tree.Delete(i)
+		tree.DeleteMin()
+		tree.DeleteMax()
+	}
+	removed := maxKeys - tree.Size()
+	if counts[EventNodeRemoved] != removed {
+		t.Errorf("NodeRemoved: expected: %d, got: %d", removed, counts[EventNodeRemoved])
+	}
+	if counts[EventMoveRedLeft] == 0 || counts[EventMoveRedRight] == 0 || counts[EventRotateRight] == 0 {
+		t.Errorf("Delete: expected moves and right rotations, got: %v", counts)
+	}
+}
+
+func TestEventKindString(t *testing.T) {
+	if EventMoveRedRight.String() != "MoveRedRight" {
+		t.Errorf("EventKind.String: expected: MoveRedRight, got: %s", EventMoveRedRight)
+	}
+	if EventKind(99).String() != "EventKind(99)" {
+		t.Errorf("EventKind.String: expected: EventKind(99), got: %s", EventKind(99))
+	}
+}
+
+func TestTracerLiveNodes(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestTracerLiveNodes")
+	tree := NewOrdered[int, int]()
+	live := 0
+	tree.SetTracer(TracerFunc[int](func(e Event[int]) {
+		switch e.Kind {
+		case EventNodeCreated:
+			live++
+		case EventNodeRemoved:
+			live--
+		}
+	}))
+	check := func(op string) {
+		t.Helper()
+		if live != tree.Size() {
+			t.Errorf("%s: created minus removed: expected: %d, got: %d", op, tree.Size(), live)
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		tree.Put(i, i)
+	}
+	check("Put")
+	tree.DeleteRange(100, 199)
+	check("DeleteRange")
+	tree.DeleteRange(250, 250)
+	check("DeleteRange")
+	tree.DeleteBefore(50)
+	check("DeleteBefore")
+	tree.DeleteAfter(900)
+	check("DeleteAfter")
+	tree.Update(500, func(old int, found bool) (int, Action) { return old, DeleteKey })
+	check("Update")
+	tree.Update(2000, func(old int, found bool) (int, Action) { return 1, StoreValue })
+	check("Update")
+	tree.Update(600, func(old int, found bool) (int, Action) { return 1, StoreValue })
+	check("Update")
+	tree.Delete(300)
+	check("Delete")
+	tree.DeleteMin()
+	check("DeleteMin")
+	tree.DeleteMax()
+	check("DeleteMax")
+	tree.Split(400)
+	check("Split")
+}
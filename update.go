@@ -0,0 +1,137 @@
+package rbtree
+
+// Action tells Update what to do with a key.
+type Action int
+
+const (
+	// KeepValue leaves the tree unchanged.
+	KeepValue Action = iota
+	// StoreValue inserts the key with the returned value, or replaces the
+	// value it has.
+	StoreValue
+	// DeleteKey removes the key if the tree holds it.
+	DeleteKey
+)
+
+// Updater is implemented by the trees that support Update.
+type Updater[K, V any] interface {
+	Update(key K, fn func(old V, found bool) (V, Action)) (V, bool)
+}
+
+// Update calls fn once with the value of key and whether the tree holds it,
+// then inserts, replaces or deletes key as the returned Action says, all in a
+// single descent. It returns the value key has afterwards and whether the
+// tree still holds it. fn must not use the tree.
+func (tree *RBTree[K, V]) Update(key K, fn func(old V, found bool) (V, Action)) (V, bool) {
+	var result updateResult[K, V]
+	root, _ := tree.update(tree.root, blackHeight(tree.root), key, fn, &result)
+	if result.action != KeepValue {
+		tree.setRoot(root)
+	}
+	return result.value, result.found
+}
+
+// updateResult is what fn decided, and the key's entry afterwards.
+type updateResult[K, V any] struct {
+	entry[K, V]
+	action Action
+}
+
+// update searches for key without changing anything, asks fn what to do and
+// rebuilds the path on the way back up: not at all for KeepValue, as put does
+// for StoreValue, and by joining each parent with its other subtree for
+// DeleteKey, as split does. It returns the new subtree and, after a delete,
+// its black height; bh is the black height of node. The joins only rebuild
+// nodes for keys the tree still holds, so a delete reports a single
+// EventNodeRemoved and no EventNodeCreated.
+func (tree *RBTree[K, V]) update(node *Node[K, V], bh int, key K, fn func(old V, found bool) (V, Action), result *updateResult[K, V]) (*Node[K, V], int) {
+	if node == nil {
+		var zero V
+		value, action := fn(zero, false)
+		if action != StoreValue {
+			// nothing to delete
+			return nil, 0
+		}
+		result.action = StoreValue
+		node = tree.addNode(key, value, RED)
+		result.set(node)
+		return node, 0
+	}
+	childBh := childBlackHeight(node, bh)
+	cmp := tree.compare(key, node.key)
+	if cmp == 0 {
+		value, action := fn(node.value, true)
+		if action == DeleteKey {
+			result.action = DeleteKey
+			tree.trace(EventNodeRemoved, node)
+			return tree.join2(node.left, childBh, node.right, childBh)
+		}
+		if action != StoreValue {
+			result.set(node)
+			return node, bh
+		}
+		result.action = StoreValue
+		node = tree.mutable(node)
+		node.key = key
+		node.value = value
+		result.set(node)
+		return node, bh
+	}
+
+	var child *Node[K, V]
+	var cbh int
+	if cmp < 0 {
+		child, cbh = tree.update(node.left, childBh, key, fn, result)
+	} else {
+		child, cbh = tree.update(node.right, childBh, key, fn, result)
+	}
+	switch result.action {
+	case StoreValue:
+		node = tree.mutable(node)
+		if cmp < 0 {
+			node.left = child
+		} else {
+			node.right = child
+		}
+		return tree.fixUp(node), bh
+	case DeleteKey:
+		if cmp < 0 {
+			return tree.join3(child, cbh, node, node.right, childBh)
+		}
+		return tree.join3(node.left, childBh, node, child, cbh)
+	default:
+		return node, bh
+	}
+}
+
+// GetOrInsert returns the value of key and true if the tree holds it, and
+// otherwise inserts key with value and returns value and false.
+func (tree *RBTree[K, V]) GetOrInsert(key K, value V) (V, bool) {
+	return getOrInsert[K, V](tree, key, value)
+}
+
+func getOrInsert[K, V any](tree Updater[K, V], key K, value V) (V, bool) {
+	loaded := false
+	actual, _ := tree.Update(key, func(old V, found bool) (V, Action) {
+		if found {
+			loaded = true
+			return old, KeepValue
+		}
+		return value, StoreValue
+	})
+	return actual, loaded
+}
+
+// CompareAndSwap replaces the value of key with new if the tree holds key
+// with the value old, and reports whether it did.
+func CompareAndSwap[K any, V comparable](tree Updater[K, V], key K, old, new V) bool {
+	swapped := false
+	tree.Update(key, func(value V, found bool) (V, Action) {
+		if found && value == old {
+			swapped = true
+			return new, StoreValue
+		}
+		return value, KeepValue
+	})
+	return swapped
+}
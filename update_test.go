@@ -0,0 +1,157 @@
+package rbtree
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func increment(old int, found bool) (int, Action) {
+	return old + 1, StoreValue
+}
+
+func TestUpdate(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestUpdate")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < maxKeys; i++ {
+		tree.Update(i%100, increment)
+	}
+	for i := 0; i < 100; i++ {
+		if value, _ := tree.Get(i); value != maxKeys/100+btoi(i < maxKeys%100) {
+			t.Errorf("tree.Update: key %d expected: %d, got: %d", i, maxKeys/100+btoi(i < maxKeys%100), value)
+		}
+	}
+
+	snapshot := tree.Snapshot()
+	for i := 0; i < 100; i += 2 {
+		value, ok := tree.Update(i, func(old int, found bool) (int, Action) {
+			return 0, DeleteKey
+		})
+		if ok || value != 0 {
+			t.Errorf("tree.Update: delete %d returned: %d %t", i, value, ok)
+		}
+	}
+	value, ok := tree.Update(1, func(old int, found bool) (int, Action) {
+		return -1, KeepValue
+	})
+	if !ok || value != maxKeys/100+1 {
+		t.Errorf("tree.Update: keep returned: %d %t", value, ok)
+	}
+	if value, ok := tree.Update(-5, func(old int, found bool) (int, Action) { return 0, DeleteKey }); ok || value != 0 {
+		t.Errorf("tree.Update: delete missing returned: %d %t", value, ok)
+	}
+	if err := tree.Check(); err != nil {
+		t.Errorf("tree.Check: %v", err)
+	}
+	if tree.Size() != 50 || snapshot.Size() != 100 {
+		t.Errorf("tree.Update: sizes expected: 50 and 100, got: %d and %d", tree.Size(), snapshot.Size())
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestGetOrInsert(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestGetOrInsert")
+	tree := NewOrdered[string, int]()
+	if value, loaded := tree.GetOrInsert("a", 1); loaded || value != 1 {
+		t.Errorf("tree.GetOrInsert: expected: 1 false, got: %d %t", value, loaded)
+	}
+	if value, loaded := tree.GetOrInsert("a", 2); !loaded || value != 1 {
+		t.Errorf("tree.GetOrInsert: expected: 1 true, got: %d %t", value, loaded)
+	}
+	if tree.Size() != 1 {
+		t.Errorf("tree.GetOrInsert: size expected: 1, got: %d", tree.Size())
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestCompareAndSwap")
+	tree := NewOrdered[string, int]()
+	if CompareAndSwap(tree, "a", 0, 1) {
+		t.Error("CompareAndSwap: swapped missing key")
+	}
+	tree.Put("a", 1)
+	if CompareAndSwap(tree, "a", 2, 3) {
+		t.Error("CompareAndSwap: swapped wrong value")
+	}
+	if !CompareAndSwap(tree, "a", 1, 3) {
+		t.Error("CompareAndSwap: did not swap")
+	}
+	if value, _ := tree.Get("a"); value != 3 || tree.Contains("b") {
+		t.Errorf("CompareAndSwap: expected: 3, got: %d", value)
+	}
+}
+
+func TestConcurrentUpdate(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestConcurrentUpdate")
+	const goroutines, n = 8, 1000
+	c := NewConcurrent(NewOrdered[int, int]())
+	a := NewAtomic(NewOrdered[int, int]())
+	a.Put(-1, 0)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				c.Update(i%10, increment)
+				a.Update(i%10, increment)
+				c.GetOrInsert(100+i, i)
+				a.GetOrInsert(100+i, i)
+				for swapped := false; !swapped; {
+					old, _ := a.Get(-1)
+					swapped = CompareAndSwap[int, int](a, -1, old, old+1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 10; i++ {
+		cv, _ := c.Get(i)
+		av, _ := a.Get(i)
+		if cv != goroutines*n/10 || av != goroutines*n/10 {
+			t.Errorf("Update: key %d expected: %d, got: %d and %d", i, goroutines*n/10, cv, av)
+		}
+	}
+	if swaps, _ := a.Get(-1); swaps != goroutines*n {
+		t.Errorf("CompareAndSwap: expected: %d, got: %d", goroutines*n, swaps)
+	}
+	if c.Size() != 10+n || a.Size() != 11+n {
+		t.Errorf("GetOrInsert: sizes expected: %d and %d, got: %d and %d", 10+n, 11+n, c.Size(), a.Size())
+	}
+}
+
+func TestUpdateDeleteDoesNotAllocate(t *testing.T) {
+	defer logElapsedTime(time.Now(), "TestUpdateDeleteDoesNotAllocate")
+	tree := NewOrdered[int, int]()
+	for i := 0; i < 1000; i++ {
+		tree.Put(i, i)
+	}
+	remove := func(int, bool) (int, Action) {
+		return 0, DeleteKey
+	}
+
+	// the joins reuse the nodes on the path, so only the Put allocates
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.Update(500, remove)
+		tree.Put(500, 500)
+	})
+	if allocs != 1 {
+		t.Errorf("tree.Update then tree.Put: expected: %d allocations, got: %v", 1, allocs)
+	}
+	allocs = testing.AllocsPerRun(100, func() {
+		tree.DeleteRange(500, 500)
+		tree.Put(500, 500)
+	})
+	if allocs != 1 {
+		t.Errorf("tree.DeleteRange then tree.Put: expected: %d allocations, got: %v", 1, allocs)
+	}
+	if err := tree.Check(); err != nil {
+		t.Error(err)
+	}
+}